@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	_ "time/tzdata" // embed the timezone database for guild quiet hours, the container doesn't ship one
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
 	"github.com/mocbotau/api-join-sound/internal/database"
 	"github.com/mocbotau/api-join-sound/internal/handlers"
+	"github.com/mocbotau/api-join-sound/internal/jobs"
 	"github.com/mocbotau/api-join-sound/internal/middleware"
+	"github.com/mocbotau/api-join-sound/internal/signing"
+	"github.com/mocbotau/api-join-sound/internal/storage"
 	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
@@ -24,11 +30,6 @@ func main() {
 		dbPath = "./data/main.db"
 	}
 
-	soundsFilePath := os.Getenv("SOUNDS_PATH")
-	if soundsFilePath == "" {
-		soundsFilePath = "./data/sounds"
-	}
-
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8081"
@@ -42,9 +43,49 @@ func main() {
 		log.Fatalf("AUTH0_AUDIENCE is required")
 	}
 
-	// this folder should be created by the container/kubernetes by default
-	if err := os.MkdirAll(soundsFilePath, 0o750); err != nil {
-		log.Fatal("Failed to create data directory:", err)
+	versionRetention, err := utils.GetEnvDuration("SOUND_VERSION_RETENTION", utils.DefaultVersionRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trashRetention, err := utils.GetEnvDuration("TRASH_RETENTION", utils.DefaultTrashRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trashCountsTowardQuota, err := utils.GetEnvBool("TRASH_COUNTS_TOWARD_QUOTA", false)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reconcileInterval, err := utils.GetEnvDuration("RECONCILE_INTERVAL", utils.DefaultReconcileInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reconcileOptions, err := reconcileOptionsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scrubInterval, err := utils.GetEnvDuration("SCRUB_INTERVAL", utils.DefaultScrubInterval)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	scrubBatchSize, err := utils.GetEnvInt("SCRUB_BATCH_SIZE", utils.DefaultScrubBatchSize)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := storage.NewFromEnv(context.Background())
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	signer, err := signing.NewFromEnv()
+	if err != nil {
+		log.Fatal("Failed to initialize sound URL signing:", err)
 	}
 
 	db, err := database.NewSQLiteDB(dbPath)
@@ -61,7 +102,24 @@ func main() {
 		_ = sqlDB.Close()
 	}()
 
-	handler := handlers.NewHandler(db, soundsFilePath)
+	handler := handlers.NewHandler(db, store, signer, trashCountsTowardQuota)
+
+	go jobs.Run(context.Background(), "purge sound versions", utils.PurgeInterval,
+		jobs.PurgeSoundVersions(db, store, versionRetention))
+	go jobs.Run(context.Background(), "purge trash", utils.PurgeInterval,
+		jobs.PurgeTrash(db, store, trashRetention))
+	go jobs.Run(context.Background(), "remove scheduled files", utils.FileRemovalInterval,
+		jobs.RemoveScheduledFiles(db, store))
+
+	if reconcileInterval > 0 {
+		go jobs.Run(context.Background(), "reconcile storage", reconcileInterval,
+			jobs.ReconcileStorage(db, store, reconcileOptions))
+	}
+
+	if scrubInterval > 0 {
+		go jobs.Run(context.Background(), "scrub storage", scrubInterval,
+			jobs.ScrubStorage(db, store, scrubBatchSize))
+	}
 
 	r := gin.Default()
 
@@ -72,7 +130,7 @@ func main() {
 			c.Header("Access-Control-Allow-Credentials", "true")
 		}
 
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if c.Request.Method == http.MethodOptions {
@@ -88,21 +146,90 @@ func main() {
 		c.Next()
 	})
 
+	// public listings include signed URLs when the caller is a member of the guild
+	allowValidToken := middleware.AllowValidToken()
+
 	v1Public := r.Group("/api/v1")
 	{
 		v1Public.GET("/ping", handler.Ping)
 
 		v1Public.GET("/sound/:soundId", handler.GetSound)
-		v1Public.GET("/sounds/:guildId/:userId", handler.GetUserSounds)
+		v1Public.GET("/sounds/:guildId/:userId", allowValidToken, middleware.IdentifyGuildMember(db), handler.GetUserSounds)
 		v1Public.GET("/settings/:guildId/:userId", handler.GetUserSettings)
+		v1Public.GET("/guilds/:guildId/leaderboard", handler.GetGuildLeaderboard)
+		v1Public.GET("/guilds/:guildId/policy", handler.GetGuildPolicy)
+		v1Public.GET("/guilds/:guildId/library", allowValidToken, middleware.IdentifyGuildMember(db), handler.GetGuildLibrary)
 	}
 
-	v1Private := r.Group("/api/v1/", middleware.EnsureValidToken())
+	ensureValidToken := middleware.EnsureValidToken()
+
+	v1Private := r.Group("/api/v1/", ensureValidToken)
 	{
+		v1Private.PATCH("/sound/:soundId", middleware.EnsureResourceOwnership(db), handler.UpdateSound)
 		v1Private.DELETE("/sound/:soundId", middleware.EnsureResourceOwnership(db), handler.DeleteSound)
+		v1Private.POST("/sound/:soundId/restore", middleware.EnsureTrashedResourceOwnership(db), handler.RestoreSound)
+		v1Private.PUT("/sound/:soundId/file", middleware.EnsureResourceOwnership(db), handler.ReplaceSoundFile)
+		v1Private.GET("/sound/:soundId/url", middleware.EnsureGuildMembership(db), handler.GetSoundURL)
+		v1Private.GET("/sound/:soundId/versions", middleware.EnsureResourceOwnership(db), handler.GetSoundVersions)
+		v1Private.POST("/sound/:soundId/versions/:version/rollback", middleware.EnsureResourceOwnership(db), handler.RollbackSound)
+		v1Private.POST("/sound/:soundId/copy", middleware.EnsureResourceOwnership(db), handler.CopySound)
+		v1Private.GET("/sound/:soundId/shares", middleware.EnsureResourceOwnership(db), handler.GetShareTokens)
+		v1Private.POST("/sound/:soundId/shares", middleware.EnsureResourceOwnership(db), handler.CreateShareToken)
+		v1Private.DELETE("/sound/:soundId/shares/:token", middleware.EnsureResourceOwnership(db), handler.RevokeShareToken)
+		v1Private.POST("/shares/:token/redeem", middleware.EnsureUserAuthorization(), handler.RedeemShareToken)
+		v1Private.POST("/sound/:soundId/report", middleware.EnsureGuildMembership(db), handler.ReportSound)
+		v1Private.GET("/guilds/:guildId/sounds/search", middleware.EnsureGuildMembership(db), handler.SearchGuildSounds)
+
+		v1Private.POST("/sounds/:guildId/:userId", middleware.EnsureUserAuthorization(), middleware.EnsureNotBanned(db), handler.UploadUserSounds)
+		v1Private.POST("/sounds/:guildId/:userId/import", middleware.EnsureUserAuthorization(), middleware.EnsureNotBanned(db), handler.ImportUserSounds)
+		v1Private.GET("/sounds/:guildId/:userId/trash", middleware.EnsureUserAuthorization(), handler.GetUserTrash)
+		v1Private.POST("/sounds/:guildId/:userId/batch", middleware.EnsureUserAuthorization(), middleware.EnsureNotBanned(db), handler.BatchUpdateSounds)
+		v1Private.PATCH("/settings/:guildId/:userId", middleware.EnsureUserAuthorization(), middleware.EnsureNotBanned(db), handler.UpdateUserSettings)
+		v1Private.GET("/plays/:guildId/:userId", middleware.EnsureUserAuthorization(), handler.GetUserPlays)
+
+		v1Private.GET("/me/sounds", middleware.EnsureUserAuthorization(), handler.GetMySounds)
+		v1Private.POST("/me/sounds", middleware.EnsureUserAuthorization(), handler.UploadMySounds)
+		v1Private.POST("/me/sounds/import", middleware.EnsureUserAuthorization(), handler.ImportMySounds)
+		v1Private.GET("/me/settings", middleware.EnsureUserAuthorization(), handler.GetMySettings)
+		v1Private.PATCH("/me/settings", middleware.EnsureUserAuthorization(), handler.UpdateMySettings)
+		v1Private.GET("/me/export", middleware.EnsureUserAuthorization(), handler.ExportMyData)
+		v1Private.DELETE("/me", middleware.EnsureUserAuthorization(), handler.DeleteMyData)
+
+		v1Private.POST("/plays", middleware.EnsureBotAuthorization(), handler.CreatePlay)
+		v1Private.GET("/resolve/:guildId/:userId", middleware.EnsureBotAuthorization(), handler.ResolveUserSound)
+		v1Private.PUT("/guilds/:guildId/admins/:userId", middleware.EnsureBotAuthorization(), handler.AddGuildAdmin)
+		v1Private.DELETE("/guilds/:guildId/admins/:userId", middleware.EnsureBotAuthorization(), handler.RemoveGuildAdmin)
+
+		v1Private.DELETE("/users/:userId", middleware.EnsureAdminAuthorization(), handler.DeleteUserData)
+		v1Private.POST("/users/:userId/scrub", middleware.EnsureAdminAuthorization(), handler.ScrubUserSounds)
+		v1Private.GET("/storage/integrity", middleware.EnsureAdminAuthorization(), handler.GetIntegrityReport)
+	}
+
+	v1GuildAdmin := r.Group("/api/v1/guilds/:guildId", ensureValidToken, middleware.EnsureGuildAdmin(db))
+	{
+		v1GuildAdmin.GET("/settings", handler.GetGuildSettings)
+		v1GuildAdmin.PATCH("/settings", handler.UpdateGuildSettings)
+		v1GuildAdmin.GET("/admins", handler.GetGuildAdmins)
+		v1GuildAdmin.GET("/users", handler.GetGuildUsers)
+		v1GuildAdmin.GET("/storage", handler.GetGuildStorage)
+
+		v1GuildAdmin.POST("/library", handler.UploadLibrarySounds)
+		v1GuildAdmin.POST("/library/import", handler.ImportLibrarySounds)
+		v1GuildAdmin.DELETE("/library/:soundId", handler.DeleteLibrarySound)
+		v1GuildAdmin.GET("/library/trash", handler.GetLibraryTrash)
+		v1GuildAdmin.POST("/library/:soundId/restore", handler.RestoreLibrarySound)
+
+		v1GuildAdmin.GET("/moderation", handler.GetPendingSounds)
+		v1GuildAdmin.POST("/moderation/:soundId/approve", handler.ApproveSound)
+		v1GuildAdmin.POST("/moderation/:soundId/reject", handler.RejectSound)
+
+		v1GuildAdmin.GET("/reports", handler.GetGuildReports)
+		v1GuildAdmin.POST("/reports/:reportId/resolve", handler.ResolveReport)
+		v1GuildAdmin.POST("/reports/:reportId/dismiss", handler.DismissReport)
 
-		v1Private.POST("/sounds/:guildId/:userId", middleware.EnsureUserAuthorization(), handler.UploadUserSounds)
-		v1Private.PATCH("/settings/:guildId/:userId", middleware.EnsureUserAuthorization(), handler.UpdateUserSettings)
+		v1GuildAdmin.GET("/bans", handler.GetGuildBans)
+		v1GuildAdmin.PUT("/bans/:userId", handler.BanUser)
+		v1GuildAdmin.DELETE("/bans/:userId", handler.UnbanUser)
 	}
 
 	log.Printf("Server starting on port %s", port)
@@ -111,3 +238,33 @@ func main() {
 		log.Panic("Failed to start server:", err)
 	}
 }
+
+// reconcileOptionsFromEnv reads the options of the background storage reconciliation, which only reports drift unless
+// RECONCILE_FIX is set.
+func reconcileOptionsFromEnv() (*jobs.ReconcileOptions, error) {
+	fix, err := utils.GetEnvBool("RECONCILE_FIX", false)
+	if err != nil {
+		return nil, err
+	}
+
+	gracePeriod, err := utils.GetEnvDuration("RECONCILE_GRACE_PERIOD", utils.DefaultOrphanGracePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &jobs.ReconcileOptions{
+		Fix:          fix,
+		OrphanAction: os.Getenv("RECONCILE_ORPHAN_ACTION"),
+		GracePeriod:  gracePeriod,
+	}
+
+	if options.OrphanAction == "" {
+		options.OrphanAction = utils.OrphanActionQuarantine
+	}
+
+	if err := options.Validate(); err != nil {
+		return nil, fmt.Errorf("RECONCILE_ORPHAN_ACTION: %w", err)
+	}
+
+	return options, nil
+}
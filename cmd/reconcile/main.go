@@ -0,0 +1,74 @@
+// Command reconcile reconciles the stored sound files with the database once, printing the drift it finds as JSON. It
+// only reports unless -fix is given. It reads the same environment as the API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm/logger"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/jobs"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func main() {
+	options := &jobs.ReconcileOptions{}
+
+	flag.BoolVar(&options.Fix, "fix", false, "fix the drift found instead of only reporting it")
+	flag.StringVar(&options.OrphanAction, "orphans", utils.OrphanActionQuarantine,
+		"what to do with orphaned files when fixing: delete or quarantine")
+	flag.DurationVar(&options.GracePeriod, "grace", utils.DefaultOrphanGracePeriod,
+		"how old an unreferenced file must be to be treated as orphaned")
+	flag.Parse()
+
+	if err := options.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Error loading the .env file: %v... continuing", err)
+	}
+
+	// stdout is kept for the report, the database logs its queries there by default
+	logger.Default = logger.New(log.New(os.Stderr, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      logger.Warn,
+		Colorful:      false,
+	})
+
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "./data/main.db"
+	}
+
+	// the API owns the schema, so the database is used as it is
+	db, err := database.OpenSQLiteDB(dbPath)
+	if err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+
+	store, err := storage.NewFromEnv(context.Background())
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	report, err := jobs.Reconcile(context.Background(), db, store, options)
+	if err != nil {
+		log.Fatal("Failed to reconcile storage:", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		log.Fatal(err)
+	}
+}
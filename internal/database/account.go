@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// GetUserFilenames retrieves the internal filenames of every file stored for a Discord user across all guilds: those
+// of their sounds, trashed ones included, and of the previous versions of those sounds.
+func (db *DB) GetUserFilenames(userID int64) ([]string, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Preload("Versions").
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.user_id = ?", userID).
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	filenames := make([]string, 0, len(sounds))
+
+	for _, sound := range sounds {
+		filenames = append(filenames, sound.InternalFilename)
+		for _, version := range sound.Versions {
+			filenames = append(filenames, version.InternalFilename)
+		}
+	}
+
+	return filenames, nil
+}
+
+// DeleteUserData permanently deletes a Discord user's records in every guild, their sounds and everything that refers
+// to them, their settings, play history, reports, share tokens and admin rights, and records the deletion, all in a
+// single transaction. Reports they decided are kept without their ID. Bans are kept, so that they can't be lifted this
+// way. The removal of their files is scheduled in the same transaction, and the
+// caller should then remove them, see GetUserFilenames. It returns gorm.ErrRecordNotFound if there is nothing to
+// delete.
+func (db *DB) DeleteUserData(userID int64, requestedBy string) (*models.UserDeletion, error) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	deletion := &models.UserDeletion{
+		ID:          id,
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var userGuildIDs, soundIDs []string
+
+		if err := tx.Model(&models.User{}).Where("user_id = ?", userID).Pluck("id", &userGuildIDs).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Model(&models.Sound{}).Where("user_guild_id IN ?", userGuildIDs).Pluck("id", &soundIDs).Error
+		if err != nil {
+			return err
+		}
+
+		filenames, err := soundFilenames(tx, soundIDs)
+		if err != nil {
+			return err
+		}
+
+		if err := scheduleFileRemovals(tx, filenames, time.Now()); err != nil {
+			return err
+		}
+
+		// other rows may still refer to the sounds
+		err = tx.Model(&models.GuildSetting{}).
+			Where("default_sound_id IN ?", soundIDs).
+			Update("default_sound_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Setting{}).
+			Where("active_sound_id IN ?", soundIDs).
+			Update("active_sound_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		for _, model := range []any{&models.SoundVersion{}, &models.Report{}, &models.ShareToken{}, &models.GuildDefaultSound{}} {
+			if err := tx.Where("sound_id IN ?", soundIDs).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// and to the user themselves, in any guild
+		for _, erase := range []struct {
+			model any
+			query string
+		}{
+			{&models.Report{}, "reporter_id = ?"},
+			{&models.GuildAdmin{}, "user_id = ?"},
+			{&models.ShareToken{}, "created_by = ?"},
+		} {
+			if err := tx.Where(erase.query, userID).Delete(erase.model).Error; err != nil {
+				return err
+			}
+		}
+
+		// the reports they decided are kept for their guilds, without saying who decided them
+		err = tx.Model(&models.Report{}).
+			Where("resolved_by = ?", userID).
+			Update("resolved_by", nil).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("user_guild_id IN ?", userGuildIDs).Delete(&models.Setting{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deletion.Settings = result.RowsAffected
+
+		result = tx.Unscoped().Where("id IN ?", soundIDs).Delete(&models.Sound{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deletion.Sounds = result.RowsAffected
+
+		result = tx.Where("id IN ?", userGuildIDs).Delete(&models.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deletion.Users = result.RowsAffected
+
+		result = tx.Where("user_id = ?", userID).Delete(&models.Play{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		deletion.Plays = result.RowsAffected
+
+		if deletion.Users == 0 && deletion.Plays == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := tx.Create(deletion).Error; err != nil {
+			return fmt.Errorf("failed to record user deletion: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deletion, nil
+}
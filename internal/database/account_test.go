@@ -0,0 +1,112 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestDeleteUserData(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const userID, otherUserID, thirdUserID = 10, 20, 30
+
+	createSound := func(guildID, userID int64, internalFilename string) *models.Sound {
+		sound := databasetest.CreateSound(t, db, guildID, userID, internalFilename)
+
+		_, err := db.UpdateUserSetting(sound.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &sound.ID})
+		require.NoError(t, err)
+
+		return sound
+	}
+
+	guildSound := createSound(1, userID, "guild.mp3")
+	createSound(utils.GlobalGuildID, userID, "global.mp3")
+	trashedSound := createSound(2, userID, "trashed.mp3")
+	otherSound := createSound(1, otherUserID, "other.mp3")
+
+	_, err := db.ReplaceSoundFile(guildSound.ID, &models.SoundFile{OriginalName: "new.mp3", InternalFilename: "guild_v2.mp3", MimeType: "audio/mpeg"}, utils.SoundStatusApproved, nil)
+	require.NoError(t, err)
+
+	_, _, err = db.TrashSound(trashedSound.ID)
+	require.NoError(t, err)
+
+	for _, play := range []*models.CreatePlayRequest{
+		{GuildID: 1, UserID: userID, SoundID: guildSound.ID, ChannelID: 1},
+		{GuildID: 1, UserID: otherUserID, SoundID: otherSound.ID, ChannelID: 1},
+	} {
+		_, err = db.CreatePlay(play)
+		require.NoError(t, err)
+	}
+
+	// rows that refer to the user rather than to their sounds
+	report, err := db.CreateReport(otherSound, 1, userID, "too loud", 0)
+	require.NoError(t, err)
+
+	_, err = db.CreateReport(otherSound, 1, thirdUserID, "too loud", 0)
+	require.NoError(t, err)
+
+	adminID := int64(userID)
+
+	_, err = db.DecideReport(1, report.ID, utils.ReportStatusDismissed, &adminID)
+	require.NoError(t, err)
+
+	_, err = db.AddGuildAdmin(1, userID)
+	require.NoError(t, err)
+
+	_, err = db.CreateShareToken(otherSound.ID, userID, &models.CreateShareRequest{})
+	require.NoError(t, err)
+
+	filenames, err := db.GetUserFilenames(userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"guild.mp3", "guild_v2.mp3", "global.mp3", "trashed.mp3"}, filenames)
+
+	deletion, err := db.DeleteUserData(userID, utils.DeletionRequestedBySelf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deletion.Users)
+	assert.Equal(t, int64(3), deletion.Sounds)
+	assert.Equal(t, int64(3), deletion.Settings)
+	assert.Equal(t, int64(1), deletion.Plays)
+
+	manifest, err := db.GetUserExport(userID)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Users)
+	assert.Empty(t, manifest.Sounds)
+	assert.Empty(t, manifest.Settings)
+
+	// other users are left untouched
+	sound, err := db.GetSoundByID(otherSound.ID)
+	require.NoError(t, err)
+	assert.Equal(t, otherSound.ID, sound.ID)
+
+	_, total, err := db.GetPlaysByUser(1, otherUserID, &models.PlayFilter{Limit: utils.MaxPageSize})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// the user's reports, admin rights and share tokens are gone, and the reports they decided no longer name them
+	reports, err := db.GetGuildReports(1, utils.ReportStatusDismissed)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, int64(thirdUserID), reports[0].ReporterID)
+	assert.Nil(t, reports[0].ResolvedBy)
+
+	isAdmin, err := db.IsGuildAdmin(1, userID)
+	require.NoError(t, err)
+	assert.False(t, isAdmin)
+
+	shares, err := db.GetShareTokens(otherSound.ID)
+	require.NoError(t, err)
+	assert.Empty(t, shares)
+
+	// retrying once everything is gone finds nothing to delete
+	_, err = db.DeleteUserData(userID, utils.DeletionRequestedBySelf)
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
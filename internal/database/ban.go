@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// GetActiveBan retrieves a user's ban in a guild, if they have one that hasn't expired.
+func (db *DB) GetActiveBan(guildID, userID int64) (*models.Ban, error) {
+	var ban models.Ban
+
+	err := db.Where("guild_id = ? AND user_id = ?", guildID, userID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		First(&ban).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil // not being banned is not an error
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to query ban: %w", err)
+	}
+
+	return &ban, nil
+}
+
+// GetGuildBans retrieves all bans in a guild that haven't expired.
+func (db *DB) GetGuildBans(guildID int64) ([]*models.Ban, error) {
+	var bans []*models.Ban
+
+	err := db.Where("guild_id = ?", guildID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Order("created_at DESC").
+		Find(&bans).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+// BanUser bans a user in a guild, replacing any existing ban.
+func (db *DB) BanUser(guildID, userID int64, req *models.BanRequest, bannedBy *int64) (*models.Ban, error) {
+	var expiresAt *time.Time
+
+	if req.ExpiresAt != nil {
+		utc := req.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	ban := models.Ban{
+		GuildID:   guildID,
+		UserID:    userID,
+		Reason:    req.Reason,
+		ExpiresAt: expiresAt,
+		BannedBy:  bannedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&ban).Error; err != nil {
+		return nil, fmt.Errorf("failed to ban user: %w", err)
+	}
+
+	return &ban, nil
+}
+
+// UnbanUser lifts a user's ban in a guild.
+func (db *DB) UnbanUser(guildID, userID int64) error {
+	result := db.Where("guild_id = ? AND user_id = ?", guildID, userID).Delete(&models.Ban{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unban user: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
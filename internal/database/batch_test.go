@@ -0,0 +1,46 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+func TestBatchUpdateSounds(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, userID = 1, 10
+
+	user := databasetest.CreateUser(t, db, guildID, userID)
+
+	ids := make([]string, 0, 4)
+	for _, name := range []string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"} {
+		ids = append(ids, databasetest.CreateSound(t, db, guildID, userID, name).ID)
+	}
+
+	_, err := db.UpdateUserSetting(user.ID, &models.UpdateSettingsRequest{ActiveSoundID: &ids[0]})
+	require.NoError(t, err)
+
+	// the replacement must be picked after both deletions, and follow the new order
+	newSound, err := db.BatchUpdateSounds(user.ID, []string{ids[0], ids[1]}, []string{ids[3], ids[2]})
+	require.NoError(t, err)
+	require.NotNil(t, newSound)
+	assert.Equal(t, ids[3], newSound.ID)
+
+	sounds, err := db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	require.Len(t, sounds, 2)
+	assert.Equal(t, ids[3], sounds[0].ID)
+	assert.Equal(t, ids[2], sounds[1].ID)
+
+	setting, _, err := db.GetUserSetting(guildID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, setting.ActiveSoundID)
+	assert.Equal(t, ids[3], *setting.ActiveSoundID)
+}
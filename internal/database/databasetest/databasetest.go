@@ -0,0 +1,53 @@
+// Package databasetest provides the database fixtures shared by tests.
+package databasetest
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// New creates an empty database in a temporary directory that is removed once the test finishes.
+func New(t testing.TB) *database.DB {
+	t.Helper()
+
+	db, err := database.NewSQLiteDB(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+
+	return db
+}
+
+// CreateUser creates a user in a guild, or returns the existing one.
+func CreateUser(t testing.TB, db *database.DB, guildID, userID int64) *models.User {
+	t.Helper()
+
+	user, err := db.CreateOrGetUser(guildID, userID)
+	require.NoError(t, err)
+
+	return user
+}
+
+// CreateSound creates an approved sound for a user in a guild, stored under its name, creating the user if needed.
+func CreateSound(t testing.TB, db *database.DB, guildID, userID int64, name string) *models.Sound {
+	t.Helper()
+
+	file := &models.SoundFile{OriginalName: name, InternalFilename: name, MimeType: "audio/mpeg"}
+
+	return CreateSoundWithFile(t, db, guildID, userID, file, utils.SoundStatusApproved)
+}
+
+// CreateSoundWithFile creates a sound for the given file with the given review status for a user in a guild, creating
+// the user if needed.
+func CreateSoundWithFile(t testing.TB, db *database.DB, guildID, userID int64, file *models.SoundFile, status string) *models.Sound {
+	t.Helper()
+
+	sound, err := db.CreateSound(CreateUser(t, db, guildID, userID).ID, file, status, nil)
+	require.NoError(t, err)
+
+	return sound
+}
@@ -14,10 +14,50 @@ import (
 // DB is a wrapper around the GORM database connection.
 type DB struct {
 	*gorm.DB
+
+	// fullTextSearch is set if SQLite was built with FTS5, see setupSoundSearch.
+	fullTextSearch bool
 }
 
-// NewSQLiteDB creates a new SQLite database connection with GORM.
+// NewSQLiteDB creates a new SQLite database connection with GORM, migrating the schema and rebuilding the search
+// index.
 func NewSQLiteDB(dataSource string) (*DB, error) {
+	db, err := OpenSQLiteDB(dataSource)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Sound{},
+		&models.Setting{},
+		&models.Play{},
+		&models.Guild{},
+		&models.GuildSetting{},
+		&models.GuildAdmin{},
+		&models.Ban{},
+		&models.GuildDefaultSound{},
+		&models.Report{},
+		&models.ShareToken{},
+		&models.SoundVersion{},
+		&models.UserDeletion{},
+		&models.FileRemoval{},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	db.fullTextSearch, err = setupSoundSearch(db.DB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up sound search: %w", err)
+	}
+
+	return db, nil
+}
+
+// OpenSQLiteDB opens an existing SQLite database with GORM without migrating it or touching the search index, for
+// tools that run alongside the API. Searching falls back to substring matching.
+func OpenSQLiteDB(dataSource string) (*DB, error) {
 	db, err := gorm.Open(sqlite.Open(dataSource), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 		NowFunc: func() time.Time {
@@ -28,13 +68,7 @@ func NewSQLiteDB(dataSource string) (*DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	dbInstance := &DB{db}
-
 	db.Exec("PRAGMA foreign_keys = ON;")
 
-	if err := db.AutoMigrate(&models.User{}, &models.Sound{}, &models.Setting{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate database: %w", err)
-	}
-
-	return dbInstance, nil
+	return &DB{DB: db}, nil
 }
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// GetUserExport retrieves the records of a Discord user in every guild, their global profile included, along with all
+// of their sounds, those in the trash included, and their settings. The files of the sounds are left to the caller.
+func (db *DB) GetUserExport(userID int64) (*models.ExportManifest, error) {
+	manifest := &models.ExportManifest{
+		UserID:     userID,
+		ExportedAt: time.Now().UTC(),
+		Users:      make([]*models.User, 0),
+		Sounds:     make([]*models.ExportedSound, 0),
+		Settings:   make([]*models.Setting, 0),
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("guild_id ASC").Find(&manifest.Users).Error; err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Users) == 0 {
+		return manifest, nil
+	}
+
+	guildIDs := make(map[string]int64, len(manifest.Users))
+	userGuildIDs := make([]string, 0, len(manifest.Users))
+
+	for _, user := range manifest.Users {
+		guildIDs[user.ID] = user.GuildID
+		userGuildIDs = append(userGuildIDs, user.ID)
+	}
+
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Where("user_guild_id IN ?", userGuildIDs).
+		Order("created_at ASC").
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sound := range sounds {
+		manifest.Sounds = append(manifest.Sounds, &models.ExportedSound{
+			Sound:   sound,
+			GuildID: guildIDs[sound.UserGuildID],
+		})
+	}
+
+	if err := db.Where("user_guild_id IN ?", userGuildIDs).Find(&manifest.Settings).Error; err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
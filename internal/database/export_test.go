@@ -0,0 +1,57 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestGetUserExport(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const userID = 10
+
+	guildSound := databasetest.CreateSound(t, db, 1, userID, "sound.mp3")
+	globalSound := databasetest.CreateSound(t, db, utils.GlobalGuildID, userID, "sound.mp3")
+	trashedSound := databasetest.CreateSound(t, db, 2, userID, "sound.mp3")
+	databasetest.CreateSound(t, db, 1, 20, "sound.mp3")
+	databasetest.CreateSound(t, db, 1, utils.LibraryUserID, "sound.mp3")
+
+	_, _, err := db.TrashSound(trashedSound.ID)
+	require.NoError(t, err)
+
+	_, err = db.UpdateUserSetting(guildSound.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &guildSound.ID})
+	require.NoError(t, err)
+
+	manifest, err := db.GetUserExport(userID)
+	require.NoError(t, err)
+
+	guildIDs := make([]int64, 0, len(manifest.Users))
+	for _, user := range manifest.Users {
+		guildIDs = append(guildIDs, user.GuildID)
+	}
+
+	assert.Equal(t, []int64{utils.GlobalGuildID, 1, 2}, guildIDs)
+
+	soundGuilds := make(map[string]int64, len(manifest.Sounds))
+	for _, exported := range manifest.Sounds {
+		soundGuilds[exported.Sound.ID] = exported.GuildID
+	}
+
+	assert.Equal(t, map[string]int64{guildSound.ID: 1, globalSound.ID: utils.GlobalGuildID, trashedSound.ID: 2}, soundGuilds)
+
+	require.Len(t, manifest.Settings, 1)
+	assert.Equal(t, &guildSound.ID, manifest.Settings[0].ActiveSoundID)
+
+	manifest, err = db.GetUserExport(30)
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Users)
+	assert.Empty(t, manifest.Sounds)
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ScheduleFileRemoval schedules the removal of a stored file once the given time passes, replacing the time of any
+// removal already scheduled for it.
+func (db *DB) ScheduleFileRemoval(internalFilename string, removeAfter time.Time) error {
+	return scheduleFileRemovals(db.DB, []string{internalFilename}, removeAfter)
+}
+
+// GetDueFileRemovals retrieves the file removals scheduled for before the given time.
+func (db *DB) GetDueFileRemovals(now time.Time) ([]*models.FileRemoval, error) {
+	var removals []*models.FileRemoval
+
+	err := db.Where("remove_after <= ?", now.UTC()).Order("remove_after").Find(&removals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return removals, nil
+}
+
+// IsFileReferenced reports whether a sound, trashed ones included, or a sound version refers to a stored file.
+func (db *DB) IsFileReferenced(internalFilename string) (bool, error) {
+	var sounds, versions int64
+
+	err := db.Unscoped().Model(&models.Sound{}).Where("internal_filename = ?", internalFilename).Count(&sounds).Error
+	if err != nil {
+		return false, err
+	}
+
+	err = db.Model(&models.SoundVersion{}).Where("internal_filename = ?", internalFilename).Count(&versions).Error
+	if err != nil {
+		return false, err
+	}
+
+	return sounds+versions > 0, nil
+}
+
+// DeleteFileRemoval deletes the scheduled removal of a stored file, once the file is removed or a sound refers to it.
+func (db *DB) DeleteFileRemoval(internalFilename string) error {
+	if err := db.Where("internal_filename = ?", internalFilename).Delete(&models.FileRemoval{}).Error; err != nil {
+		return fmt.Errorf("failed to delete file removal: %w", err)
+	}
+
+	return nil
+}
+
+// RecordFileRemovalFailure records a failed attempt at removing a stored file, and reschedules its removal for the
+// given time.
+func (db *DB) RecordFileRemovalFailure(internalFilename string, removalErr error, retryAfter time.Time) error {
+	err := db.Model(&models.FileRemoval{}).
+		Where("internal_filename = ?", internalFilename).
+		Updates(map[string]any{
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   removalErr.Error(),
+			"remove_after": retryAfter.UTC(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record file removal failure: %w", err)
+	}
+
+	return nil
+}
+
+// scheduleFileRemovals schedules the removal of the given stored files once the given time passes.
+func scheduleFileRemovals(tx *gorm.DB, filenames []string, removeAfter time.Time) error {
+	if len(filenames) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	removals := make([]*models.FileRemoval, 0, len(filenames))
+
+	for _, filename := range filenames {
+		removals = append(removals, &models.FileRemoval{
+			InternalFilename: filename,
+			RemoveAfter:      removeAfter.UTC(),
+			CreatedAt:        now,
+		})
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "internal_filename"}},
+		DoUpdates: clause.AssignmentColumns([]string{"remove_after"}),
+	}).Create(&removals).Error
+	if err != nil {
+		return fmt.Errorf("failed to schedule file removal: %w", err)
+	}
+
+	return nil
+}
+
+// soundFilenames retrieves the internal filenames of the given sounds, trashed ones included, and of their versions.
+func soundFilenames(tx *gorm.DB, soundIDs []string) ([]string, error) {
+	var filenames, versionFilenames []string
+
+	err := tx.Unscoped().Model(&models.Sound{}).Where("id IN ?", soundIDs).Pluck("internal_filename", &filenames).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&models.SoundVersion{}).Where("sound_id IN ?", soundIDs).Pluck("internal_filename", &versionFilenames).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(filenames, versionFilenames...), nil
+}
+
+// RemoveFiles removes stored files whose removal was scheduled, skipping any that a sound or sound version refers to,
+// and settles their scheduled removals. A removal that fails is rescheduled, so that it is retried once due.
+func (db *DB) RemoveFiles(ctx context.Context, store storage.Storage, filenames []string) error {
+	var errs []error
+
+	for _, filename := range filenames {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := db.removeFile(ctx, store, filename); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// removeFile removes a single stored file for RemoveFiles.
+func (db *DB) removeFile(ctx context.Context, store storage.Storage, filename string) error {
+	referenced, err := db.IsFileReferenced(filename)
+	if err != nil {
+		return fmt.Errorf("failed to check references to %s: %w", filename, err)
+	}
+
+	if !referenced {
+		if err := store.Delete(ctx, filename); err != nil {
+			err = fmt.Errorf("failed to remove %s: %w", filename, err)
+
+			return errors.Join(err, db.RecordFileRemovalFailure(filename, err, time.Now().Add(utils.FileRemovalRetryDelay)))
+		}
+	}
+
+	return db.DeleteFileRemoval(filename)
+}
@@ -0,0 +1,87 @@
+package database_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestFileRemovals(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	// an upload schedules the removal of its file before writing it, and keeps the file once its record is written
+	require.NoError(t, db.ScheduleFileRemoval("uploaded.mp3", time.Now().Add(utils.UploadGracePeriod)))
+	require.NoError(t, db.ScheduleFileRemoval("abandoned.mp3", time.Now().Add(utils.UploadGracePeriod)))
+
+	sound := databasetest.CreateSound(t, db, 1, 10, "uploaded.mp3")
+
+	referenced, err := db.IsFileReferenced("uploaded.mp3")
+	require.NoError(t, err)
+	assert.True(t, referenced)
+
+	referenced, err = db.IsFileReferenced("abandoned.mp3")
+	require.NoError(t, err)
+	assert.False(t, referenced)
+
+	require.NoError(t, db.DeleteFileRemoval("uploaded.mp3"))
+
+	due, err := db.GetDueFileRemovals(time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	// the file of an upload that never wrote its record is removed once the grace period passes
+	due, err = db.GetDueFileRemovals(time.Now().Add(utils.UploadGracePeriod + time.Minute))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "abandoned.mp3", due[0].InternalFilename)
+
+	// purging a sound schedules the removal of its files straight away
+	_, err = db.ReplaceSoundFile(sound.ID, &models.SoundFile{OriginalName: "replaced.mp3", InternalFilename: "replaced.mp3", MimeType: "audio/mpeg"}, utils.SoundStatusApproved, nil)
+	require.NoError(t, err)
+
+	_, _, err = db.TrashSound(sound.ID)
+	require.NoError(t, err)
+
+	referenced, err = db.IsFileReferenced("replaced.mp3")
+	require.NoError(t, err)
+	assert.True(t, referenced, "trashed sounds still refer to their files")
+
+	require.NoError(t, db.PurgeSound(sound.ID))
+
+	due, err = db.GetDueFileRemovals(time.Now())
+	require.NoError(t, err)
+
+	var filenames []string
+	for _, removal := range due {
+		filenames = append(filenames, removal.InternalFilename)
+	}
+
+	assert.ElementsMatch(t, []string{"uploaded.mp3", "replaced.mp3"}, filenames)
+
+	// a failed removal is retried later
+	require.NoError(t, db.RecordFileRemovalFailure("replaced.mp3", errors.New("disk on fire"), time.Now().Add(time.Hour)))
+
+	due, err = db.GetDueFileRemovals(time.Now())
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "uploaded.mp3", due[0].InternalFilename)
+
+	due, err = db.GetDueFileRemovals(time.Now().Add(2 * time.Hour))
+	require.NoError(t, err)
+
+	for _, removal := range due {
+		if removal.InternalFilename == "replaced.mp3" {
+			assert.Equal(t, 1, removal.Attempts)
+			assert.Equal(t, "disk on fire", removal.LastError)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// CreateOrGetGuild creates a new guild or returns the existing one.
+func (db *DB) CreateOrGetGuild(guildID int64) (*models.Guild, error) {
+	guild := models.Guild{ID: guildID}
+
+	if err := db.Where(&guild).FirstOrCreate(&guild).Error; err != nil {
+		return nil, fmt.Errorf("failed to create guild: %w", err)
+	}
+
+	return &guild, nil
+}
+
+// GetGuildSetting retrieves the settings of a guild, falling back to the defaults if none have been saved.
+func (db *DB) GetGuildSetting(guildID int64) (*models.GuildSetting, error) {
+	var setting models.GuildSetting
+
+	err := db.Where("guild_id = ?", guildID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.GuildSetting{
+			GuildID:          guildID,
+			DefaultMode:      utils.DefaultGuildMode,
+			Enabled:          true,
+			Timezone:         utils.DefaultGuildTimezone,
+			DefaultSoundMode: utils.DefaultGuildMode,
+			DefaultSoundPool: []string{},
+			ReportThreshold:  utils.DefaultReportThreshold,
+		}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := db.populateDefaultSoundPool(&setting); err != nil {
+		return nil, err
+	}
+
+	return &setting, nil
+}
+
+// UpdateGuildSetting updates guild settings.
+func (db *DB) UpdateGuildSetting(guildID int64, req *models.UpdateGuildSettingsRequest) (*models.GuildSetting, error) {
+	setting, err := db.getCreateGuildSetting(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.DefaultMode != nil && *req.DefaultMode != "" {
+		setting.DefaultMode = *req.DefaultMode
+	}
+
+	if req.RequireApproval != nil {
+		setting.RequireApproval = *req.RequireApproval
+	}
+
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+
+	if req.QuietHoursStart != nil {
+		setting.QuietHoursStart = overrideOrReset(req.QuietHoursStart)
+	}
+
+	if req.QuietHoursEnd != nil {
+		setting.QuietHoursEnd = overrideOrReset(req.QuietHoursEnd)
+	}
+
+	if req.Timezone != nil {
+		setting.Timezone = *req.Timezone
+	}
+
+	if req.DefaultSoundMode != nil && *req.DefaultSoundMode != "" {
+		setting.DefaultSoundMode = *req.DefaultSoundMode
+	}
+
+	if req.DefaultSoundID != nil {
+		setting.DefaultSoundID = overrideOrReset(req.DefaultSoundID)
+	}
+
+	if req.ReportThreshold != nil {
+		setting.ReportThreshold = *req.ReportThreshold
+	}
+
+	if req.MaxAudioDurationMs != nil {
+		setting.MaxAudioDurationMs = req.MaxAudioDurationMs
+	}
+
+	if req.MaxFilesPerUser != nil {
+		setting.MaxFilesPerUser = req.MaxFilesPerUser
+	}
+
+	if req.MaxUploadSize != nil {
+		setting.MaxUploadSize = req.MaxUploadSize
+	}
+
+	if req.MaxUserStorage != nil {
+		setting.MaxUserStorage = req.MaxUserStorage
+	}
+
+	if req.MaxGuildStorage != nil {
+		setting.MaxGuildStorage = req.MaxGuildStorage
+	}
+
+	if req.AllowedTypes != nil {
+		setting.AllowedTypes = *req.AllowedTypes
+	}
+
+	resetPolicyOverrides(setting, req.ResetOverrides)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(setting).Error; err != nil {
+			return fmt.Errorf("failed to update guild setting: %w", err)
+		}
+
+		if req.DefaultSoundPool != nil {
+			return replaceDefaultSoundPool(tx, guildID, *req.DefaultSoundPool)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.populateDefaultSoundPool(setting); err != nil {
+		return nil, err
+	}
+
+	return setting, nil
+}
+
+func (db *DB) populateDefaultSoundPool(setting *models.GuildSetting) error {
+	setting.DefaultSoundPool = []string{}
+
+	// trashed sounds rejoin the pool when they are restored
+	err := db.Model(&models.GuildDefaultSound{}).
+		Joins("JOIN sounds ON sounds.id = guild_default_sounds.sound_id AND sounds.deleted_at IS NULL").
+		Where("guild_default_sounds.guild_id = ?", setting.GuildID).
+		Order("guild_default_sounds.sound_id ASC").
+		Pluck("guild_default_sounds.sound_id", &setting.DefaultSoundPool).Error
+	if err != nil {
+		return fmt.Errorf("failed to fetch default sound pool: %w", err)
+	}
+
+	return nil
+}
+
+func replaceDefaultSoundPool(tx *gorm.DB, guildID int64, soundIDs []string) error {
+	if err := tx.Where("guild_id = ?", guildID).Delete(&models.GuildDefaultSound{}).Error; err != nil {
+		return fmt.Errorf("failed to clear default sound pool: %w", err)
+	}
+
+	if len(soundIDs) == 0 {
+		return nil
+	}
+
+	pool := make([]models.GuildDefaultSound, 0, len(soundIDs))
+	for _, soundID := range soundIDs {
+		pool = append(pool, models.GuildDefaultSound{GuildID: guildID, SoundID: soundID})
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&pool).Error; err != nil {
+		return fmt.Errorf("failed to update default sound pool: %w", err)
+	}
+
+	return nil
+}
+
+// getCreateGuildSetting retrieves the settings of a guild, creating them with the defaults if they don't exist.
+func (db *DB) getCreateGuildSetting(guildID int64) (*models.GuildSetting, error) {
+	if _, err := db.CreateOrGetGuild(guildID); err != nil {
+		return nil, err
+	}
+
+	setting := models.GuildSetting{GuildID: guildID}
+
+	if err := db.Where(&setting).FirstOrCreate(&setting).Error; err != nil {
+		return nil, fmt.Errorf("failed to create guild setting: %w", err)
+	}
+
+	return &setting, nil
+}
+
+// IsGuildAdmin checks whether a user has been marked as an admin of a guild.
+func (db *DB) IsGuildAdmin(guildID, userID int64) (bool, error) {
+	var count int64
+
+	err := db.Model(&models.GuildAdmin{}).
+		Where("guild_id = ? AND user_id = ?", guildID, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to query guild admin: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// GetGuildAdmins retrieves all admins of a guild.
+func (db *DB) GetGuildAdmins(guildID int64) ([]*models.GuildAdmin, error) {
+	var admins []*models.GuildAdmin
+
+	err := db.Where("guild_id = ?", guildID).
+		Order("created_at ASC").
+		Find(&admins).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return admins, nil
+}
+
+// AddGuildAdmin marks a user as an admin of a guild. Adding an existing admin is a no-op.
+func (db *DB) AddGuildAdmin(guildID, userID int64) (*models.GuildAdmin, error) {
+	if _, err := db.CreateOrGetGuild(guildID); err != nil {
+		return nil, err
+	}
+
+	admin := models.GuildAdmin{
+		GuildID: guildID,
+		UserID:  userID,
+	}
+
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&admin).Error; err != nil {
+		return nil, fmt.Errorf("failed to add guild admin: %w", err)
+	}
+
+	return &admin, nil
+}
+
+// RemoveGuildAdmin revokes a user's admin status in a guild.
+func (db *DB) RemoveGuildAdmin(guildID, userID int64) error {
+	result := db.Where("guild_id = ? AND user_id = ?", guildID, userID).Delete(&models.GuildAdmin{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove guild admin: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetGuildUserOverviews retrieves the sounds and settings of every user in a guild.
+func (db *DB) GetGuildUserOverviews(guildID int64) ([]*models.UserOverview, error) {
+	var users []*models.User
+
+	err := db.Where("guild_id = ? AND user_id <> ?", guildID, utils.LibraryUserID).
+		Preload("Sounds", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("broken = ?", false).Order("created_at DESC")
+		}).
+		Preload("Settings").
+		Order("user_id ASC").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	overviews := make([]*models.UserOverview, 0, len(users))
+	for _, user := range users {
+		overviews = append(overviews, &models.UserOverview{
+			User:    user,
+			Setting: user.Settings,
+			Sounds:  user.Sounds,
+		})
+	}
+
+	return overviews, nil
+}
+
+// resetPolicyOverrides clears the named policy overrides, so that the global defaults apply again.
+func resetPolicyOverrides(setting *models.GuildSetting, names []string) {
+	for _, name := range names {
+		switch name {
+		case "max_audio_duration_ms":
+			setting.MaxAudioDurationMs = nil
+		case "max_files_per_user":
+			setting.MaxFilesPerUser = nil
+		case "max_upload_size":
+			setting.MaxUploadSize = nil
+		case "max_user_storage":
+			setting.MaxUserStorage = nil
+		case "max_guild_storage":
+			setting.MaxGuildStorage = nil
+		case "allowed_types":
+			setting.AllowedTypes = nil
+		}
+	}
+}
+
+// overrideOrReset returns the given override, or nil if it is the zero value and should reset to the default.
+func overrideOrReset[T comparable](value *T) *T {
+	var zero T
+	if *value == zero {
+		return nil
+	}
+
+	return value
+}
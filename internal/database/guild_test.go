@@ -0,0 +1,47 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestUpdateGuildSettingPolicyOverrides(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID = 1
+
+	setting, err := db.GetGuildSetting(guildID)
+	require.NoError(t, err)
+	assert.Equal(t, utils.DefaultReportThreshold, setting.ReportThreshold)
+	assert.Equal(t, utils.DefaultPolicy(), utils.ResolvePolicy(setting))
+
+	noFiles := 0
+	noStorageLimit := int64(utils.NoStorageLimit)
+
+	setting, err = db.UpdateGuildSetting(guildID, &models.UpdateGuildSettingsRequest{
+		MaxFilesPerUser: &noFiles,
+		MaxUserStorage:  &noStorageLimit,
+	})
+	require.NoError(t, err)
+
+	policy := utils.ResolvePolicy(setting)
+	assert.Zero(t, policy.MaxFilesPerUser)
+	assert.Equal(t, int64(utils.NoStorageLimit), policy.MaxUserStorage)
+
+	setting, err = db.UpdateGuildSetting(guildID, &models.UpdateGuildSettingsRequest{
+		ResetOverrides: []string{"max_files_per_user"},
+	})
+	require.NoError(t, err)
+
+	policy = utils.ResolvePolicy(setting)
+	assert.Equal(t, utils.DefaultMaxFilesPerUser, policy.MaxFilesPerUser)
+	assert.Equal(t, int64(utils.NoStorageLimit), policy.MaxUserStorage, "other overrides are kept")
+}
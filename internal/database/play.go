@@ -0,0 +1,165 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// CreatePlay records a playback of a sound.
+func (db *DB) CreatePlay(req *models.CreatePlayRequest) (*models.Play, error) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	playedAt := time.Now().UTC()
+	if req.PlayedAt != nil {
+		playedAt = req.PlayedAt.UTC()
+	}
+
+	play := models.Play{
+		ID:        id,
+		GuildID:   req.GuildID,
+		UserID:    req.UserID,
+		SoundID:   req.SoundID,
+		ChannelID: req.ChannelID,
+		PlayedAt:  playedAt,
+	}
+
+	if err := db.Create(&play).Error; err != nil {
+		return nil, fmt.Errorf("failed to create play: %w", err)
+	}
+
+	return &play, nil
+}
+
+// GetPlaysByUser retrieves a page of a user's play history, newest first, along with the total number of matches.
+func (db *DB) GetPlaysByUser(guildID, userID int64, filter *models.PlayFilter) ([]*models.Play, int64, error) {
+	query := filterPlays(db.Model(&models.Play{}), filter).
+		Where("guild_id = ? AND user_id = ?", guildID, userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	plays := make([]*models.Play, 0)
+
+	err := query.
+		Order("played_at DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&plays).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return plays, total, nil
+}
+
+// GetTopSounds retrieves the most played sounds in a guild.
+func (db *DB) GetTopSounds(guildID int64, filter *models.PlayFilter) ([]*models.SoundPlayStat, error) {
+	stats := make([]*models.SoundPlayStat, 0)
+
+	err := filterPlays(db.Model(&models.Play{}), filter).
+		Select("plays.sound_id, COALESCE(sounds.original_name, '') AS original_name, "+
+			"COALESCE(sounds.display_name, '') AS display_name, COUNT(*) AS play_count").
+		Joins("LEFT JOIN sounds ON sounds.id = plays.sound_id").
+		Where("plays.guild_id = ?", guildID).
+		Group("plays.sound_id").
+		Order("play_count DESC").
+		Limit(filter.Limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetTopUsers retrieves the users whose sounds have been played the most in a guild.
+func (db *DB) GetTopUsers(guildID int64, filter *models.PlayFilter) ([]*models.UserPlayStat, error) {
+	stats := make([]*models.UserPlayStat, 0)
+
+	err := filterPlays(db.Model(&models.Play{}), filter).
+		Select("user_id, COUNT(*) AS play_count").
+		Where("guild_id = ?", guildID).
+		Group("user_id").
+		Order("play_count DESC").
+		Limit(filter.Limit).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// HasPlayedInGuild checks whether the bot has ever played a join sound for a user in a guild.
+func (db *DB) HasPlayedInGuild(guildID, userID int64) (bool, error) {
+	var count int64
+
+	err := db.Model(&models.Play{}).
+		Where("guild_id = ? AND user_id = ?", guildID, userID).
+		Limit(1).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to query plays: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// populatePlayCounts fills in the play count of each of the given sounds.
+func (db *DB) populatePlayCounts(sounds []*models.Sound) error {
+	if len(sounds) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(sounds))
+	for _, sound := range sounds {
+		ids = append(ids, sound.ID)
+	}
+
+	var counts []struct {
+		SoundID   string
+		PlayCount int64
+	}
+
+	err := db.Model(&models.Play{}).
+		Select("sound_id, COUNT(*) AS play_count").
+		Where("sound_id IN ?", ids).
+		Group("sound_id").
+		Scan(&counts).Error
+	if err != nil {
+		return fmt.Errorf("failed to count plays: %w", err)
+	}
+
+	countByID := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		countByID[count.SoundID] = count.PlayCount
+	}
+
+	for _, sound := range sounds {
+		sound.PlayCount = countByID[sound.ID]
+	}
+
+	return nil
+}
+
+func filterPlays(query *gorm.DB, filter *models.PlayFilter) *gorm.DB {
+	if filter.From != nil {
+		query = query.Where("plays.played_at >= ?", filter.From.UTC())
+	}
+
+	if filter.To != nil {
+		query = query.Where("plays.played_at < ?", filter.To.UTC())
+	}
+
+	return query
+}
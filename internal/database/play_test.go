@@ -0,0 +1,131 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+func TestPlayStatistics(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	first := databasetest.CreateSound(t, db, 1, 10, "first.mp3")
+	second := databasetest.CreateSound(t, db, 1, 10, "second.mp3")
+
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lastHour := start.Add(2 * time.Hour)
+
+	for i, soundID := range []string{first.ID, first.ID, second.ID} {
+		playedAt := start.Add(time.Duration(i) * time.Hour)
+
+		_, err := db.CreatePlay(&models.CreatePlayRequest{
+			GuildID:   1,
+			UserID:    10,
+			SoundID:   soundID,
+			ChannelID: 100,
+			PlayedAt:  &playedAt,
+		})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name          string
+		filter        models.PlayFilter
+		wantTotal     int64
+		wantPlays     int
+		wantTopSound  string
+		wantTopCount  int64
+		wantUserCount int64
+	}{
+		{
+			name:          "All time",
+			filter:        models.PlayFilter{Limit: 10},
+			wantTotal:     3,
+			wantPlays:     3,
+			wantTopSound:  first.ID,
+			wantTopCount:  2,
+			wantUserCount: 3,
+		},
+		{
+			name:          "Paginated",
+			filter:        models.PlayFilter{Limit: 1, Offset: 1},
+			wantTotal:     3,
+			wantPlays:     1,
+			wantTopSound:  first.ID,
+			wantTopCount:  2,
+			wantUserCount: 3,
+		},
+		{
+			name:          "Time range",
+			filter:        models.PlayFilter{Limit: 10, From: &lastHour},
+			wantTotal:     1,
+			wantPlays:     1,
+			wantTopSound:  second.ID,
+			wantTopCount:  1,
+			wantUserCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plays, total, err := db.GetPlaysByUser(1, 10, &tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Len(t, plays, tt.wantPlays)
+
+			topSounds, err := db.GetTopSounds(1, &tt.filter)
+			require.NoError(t, err)
+			require.NotEmpty(t, topSounds)
+			assert.Equal(t, tt.wantTopSound, topSounds[0].SoundID)
+			assert.Equal(t, tt.wantTopCount, topSounds[0].PlayCount)
+
+			topUsers, err := db.GetTopUsers(1, &tt.filter)
+			require.NoError(t, err)
+			require.Len(t, topUsers, 1)
+			assert.Equal(t, tt.wantUserCount, topUsers[0].PlayCount)
+		})
+	}
+
+	sounds, err := db.GetSoundsByUser(1, 10)
+	require.NoError(t, err)
+
+	for _, sound := range sounds {
+		if sound.ID == first.ID {
+			assert.Equal(t, int64(2), sound.PlayCount)
+		}
+	}
+}
+
+func TestPlayStatisticsWithoutPlays(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	filter := &models.PlayFilter{Limit: 10}
+
+	// empty results are listed as [] rather than null
+	plays, total, err := db.GetPlaysByUser(1, 10, filter)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.NotNil(t, plays)
+	assert.Empty(t, plays)
+
+	topSounds, err := db.GetTopSounds(1, filter)
+	require.NoError(t, err)
+	assert.NotNil(t, topSounds)
+	assert.Empty(t, topSounds)
+
+	topUsers, err := db.GetTopUsers(1, filter)
+	require.NoError(t, err)
+	assert.NotNil(t, topUsers)
+	assert.Empty(t, topUsers)
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ResolveSound determines which sound should be played when a user joins a channel in a guild, and where it came
+// from. The user's settings for the guild take precedence over their global settings, which are used when the guild
+// settings don't give a playable sound, and users without a playable sound of their own fall back to the guild default.
+// Only approved sounds are ever picked, and global sounds are skipped in guilds that require approval. A nil sound
+// means there is nothing to play.
+func (db *DB) ResolveSound(guildID, userID int64) (*models.Sound, string, error) {
+	guildSetting, err := db.GetGuildSetting(guildID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sound, err := db.resolveUserSound(guildSetting, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if sound != nil {
+		return sound, utils.SoundSourceUser, nil
+	}
+
+	sound, err = db.resolveGuildDefaultSound(guildSetting)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if sound != nil {
+		return sound, utils.SoundSourceGuildDefault, nil
+	}
+
+	return nil, utils.SoundSourceNone, nil
+}
+
+// resolveUserSound picks the sound of the user's settings for the guild, or of their global settings if those don't
+// give a playable sound and the guild allows global sounds.
+func (db *DB) resolveUserSound(guildSetting *models.GuildSetting, userID int64) (*models.Sound, error) {
+	allowGlobal := !guildSetting.RequireApproval
+
+	setting, err := db.findSetting(guildSetting.GuildID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if setting != nil {
+		sound, err := db.resolveSettingSound(setting, userID, allowGlobal)
+		if err != nil || sound != nil {
+			return sound, err
+		}
+	}
+
+	if !allowGlobal {
+		return nil, nil //nolint:nilnil // no playable sound in the guild settings
+	}
+
+	setting, err = db.findSetting(utils.GlobalGuildID, userID)
+	if err != nil || setting == nil {
+		return nil, err
+	}
+
+	return db.resolveSettingSound(setting, userID, allowGlobal)
+}
+
+// resolveSettingSound picks the sound that the given settings of a user play, if it is playable.
+func (db *DB) resolveSettingSound(setting *models.Setting, userID int64, allowGlobal bool) (*models.Sound, error) {
+	var query *gorm.DB
+
+	switch setting.Mode {
+	case "random":
+		owners := db.Model(&models.User{}).Select("id").Where("id = ?", setting.UserGuildID)
+		if allowGlobal {
+			owners = owners.Or("guild_id = ? AND user_id = ?", utils.GlobalGuildID, userID)
+		}
+
+		query = db.Where("user_guild_id IN (?)", owners).Order("RANDOM()")
+	default:
+		if setting.ActiveSoundID == nil {
+			return nil, nil //nolint:nilnil // no active sound means no sound
+		}
+
+		// the active sound may come from the guild library or the user's global sounds rather than their own
+		query = db.Where("id = ?", *setting.ActiveSoundID)
+		if !allowGlobal {
+			globalUsers := db.Model(&models.User{}).Select("id").Where("guild_id = ?", utils.GlobalGuildID)
+			query = query.Where("user_guild_id NOT IN (?)", globalUsers)
+		}
+	}
+
+	return firstPlayableSound(query)
+}
+
+func (db *DB) resolveGuildDefaultSound(setting *models.GuildSetting) (*models.Sound, error) {
+	var query *gorm.DB
+
+	switch setting.DefaultSoundMode {
+	case "random":
+		query = db.Joins("JOIN guild_default_sounds ON guild_default_sounds.sound_id = sounds.id").
+			Where("guild_default_sounds.guild_id = ?", setting.GuildID).
+			Order("RANDOM()")
+	default:
+		if setting.DefaultSoundID == nil {
+			return nil, nil //nolint:nilnil // no default sound configured
+		}
+
+		query = db.Where("id = ?", *setting.DefaultSoundID)
+	}
+
+	return firstPlayableSound(query)
+}
+
+// findSetting retrieves a user's settings in a guild, if they have any.
+func (db *DB) findSetting(guildID, userID int64) (*models.Setting, error) {
+	var setting models.Setting
+
+	err := db.Joins("JOIN users ON users.id = settings.user_guild_id").
+		Where("users.guild_id = ? AND users.user_id = ?", guildID, userID).
+		First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil // no settings
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &setting, nil
+}
+
+// firstPlayableSound retrieves the first approved sound matched by the query that hasn't been hidden, broken or found
+// corrupt, if any.
+func firstPlayableSound(query *gorm.DB) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := query.Where("sounds.status = ? AND sounds.hidden = ? AND sounds.broken = ? AND sounds.corrupt = ?",
+		utils.SoundStatusApproved, false, false, false).
+		First(&sound).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil // no playable sound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
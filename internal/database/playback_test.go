@@ -0,0 +1,124 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestResolveSound(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID = 1
+
+	active := databasetest.CreateSound(t, db, guildID, 10, "active.mp3")
+
+	_, err := db.UpdateUserSetting(active.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &active.ID})
+	require.NoError(t, err)
+
+	pendingFile := &models.SoundFile{OriginalName: "pending.mp3", InternalFilename: "pending.mp3", MimeType: "audio/mpeg"}
+	pending := databasetest.CreateSoundWithFile(t, db, guildID, 20, pendingFile, utils.SoundStatusPending)
+
+	random := "random"
+
+	global := databasetest.CreateSound(t, db, utils.GlobalGuildID, 40, "global.mp3")
+
+	_, err = db.UpdateUserSetting(global.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &global.ID})
+	require.NoError(t, err)
+
+	// a user whose guild settings have no active sound
+	unsetUser := databasetest.CreateUser(t, db, guildID, 50)
+
+	_, err = db.UpdateUserSetting(unsetUser.ID, &models.UpdateSettingsRequest{})
+	require.NoError(t, err)
+
+	unsetGlobal := databasetest.CreateSound(t, db, utils.GlobalGuildID, 50, "unset.mp3")
+
+	_, err = db.UpdateUserSetting(unsetGlobal.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &unsetGlobal.ID})
+	require.NoError(t, err)
+
+	requireApproval := true
+
+	_, err = db.UpdateUserSetting(pending.UserGuildID, &models.UpdateSettingsRequest{Mode: &random})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		userID     int64
+		guildReq   *models.UpdateGuildSettingsRequest
+		wantSound  string
+		wantSource string
+	}{
+		{
+			name:       "Own active sound",
+			userID:     10,
+			wantSound:  active.ID,
+			wantSource: utils.SoundSourceUser,
+		},
+		{
+			name:       "Only pending sounds and no guild default",
+			userID:     20,
+			wantSource: utils.SoundSourceNone,
+		},
+		{
+			name:       "Falls back to guild default",
+			userID:     30,
+			guildReq:   &models.UpdateGuildSettingsRequest{DefaultSoundID: &active.ID},
+			wantSound:  active.ID,
+			wantSource: utils.SoundSourceGuildDefault,
+		},
+		{
+			name:       "Falls back to guild default pool",
+			userID:     20,
+			guildReq:   &models.UpdateGuildSettingsRequest{DefaultSoundMode: &random, DefaultSoundPool: &[]string{active.ID, pending.ID}},
+			wantSound:  active.ID,
+			wantSource: utils.SoundSourceGuildDefault,
+		},
+		{
+			name:       "Global settings apply without guild settings",
+			userID:     40,
+			wantSound:  global.ID,
+			wantSource: utils.SoundSourceUser,
+		},
+		{
+			name:       "Global settings apply when guild settings have no sound",
+			userID:     50,
+			wantSound:  unsetGlobal.ID,
+			wantSource: utils.SoundSourceUser,
+		},
+		{
+			name:       "Global sounds are skipped when approval is required",
+			userID:     40,
+			guildReq:   &models.UpdateGuildSettingsRequest{RequireApproval: &requireApproval},
+			wantSound:  active.ID,
+			wantSource: utils.SoundSourceGuildDefault,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) { //nolint:paralleltest // subtests share the guild settings
+			if tt.guildReq != nil {
+				_, err := db.UpdateGuildSetting(guildID, tt.guildReq)
+				require.NoError(t, err)
+			}
+
+			sound, source, err := db.ResolveSound(guildID, tt.userID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSource, source)
+
+			if tt.wantSound == "" {
+				assert.Nil(t, sound)
+			} else {
+				require.NotNil(t, sound)
+				assert.Equal(t, tt.wantSound, sound.ID)
+			}
+		})
+	}
+}
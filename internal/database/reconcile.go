@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// GetSoundFiles retrieves the ID, internal filename, size and broken flag of every sound, trashed ones included.
+func (db *DB) GetSoundFiles() ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().Select("id", "internal_filename", "size", "broken").Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// GetKnownFilenames retrieves the internal filenames the database knows of: those of sounds, trashed ones included,
+// and sound versions, and those scheduled for removal.
+func (db *DB) GetKnownFilenames() (map[string]bool, error) {
+	var sounds, versions, removals []string
+
+	if err := db.Unscoped().Model(&models.Sound{}).Pluck("internal_filename", &sounds).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.SoundVersion{}).Pluck("internal_filename", &versions).Error; err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.FileRemoval{}).Pluck("internal_filename", &removals).Error; err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(sounds)+len(versions)+len(removals))
+
+	for _, filenames := range [][]string{sounds, versions, removals} {
+		for _, filename := range filenames {
+			known[filename] = true
+		}
+	}
+
+	return known, nil
+}
+
+// SetSoundsBroken marks the given sounds as broken, hiding them from listings and playback, or clears the mark.
+func (db *DB) SetSoundsBroken(ids []string, broken bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := db.Unscoped().Model(&models.Sound{}).Where("id IN ?", ids).Update("broken", broken).Error
+	if err != nil {
+		return fmt.Errorf("failed to update broken sounds: %w", err)
+	}
+
+	return nil
+}
+
+// SetSoundSize records the size of a sound's file.
+func (db *DB) SetSoundSize(id string, size int64) error {
+	if err := db.Unscoped().Model(&models.Sound{}).Where("id = ?", id).Update("size", size).Error; err != nil {
+		return fmt.Errorf("failed to update sound size: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ErrAlreadyReported is returned when a user reports a sound they have already reported.
+var ErrAlreadyReported = errors.New("sound already reported by this user")
+
+// CreateReport records a report of a sound, hiding the sound once the number of open reports reaches the threshold.
+// A threshold of 0 never hides the sound.
+func (db *DB) CreateReport(sound *models.Sound, guildID, reporterID int64, reason string, threshold int) (*models.Report, error) {
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	report := models.Report{
+		ID:         id,
+		SoundID:    sound.ID,
+		GuildID:    guildID,
+		ReporterID: reporterID,
+		Reason:     reason,
+		Status:     utils.ReportStatusOpen,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+
+		err := tx.Model(&models.Report{}).
+			Where("sound_id = ? AND reporter_id = ?", sound.ID, reporterID).
+			Count(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		if existing > 0 {
+			return ErrAlreadyReported
+		}
+
+		if err := tx.Create(&report).Error; err != nil {
+			return fmt.Errorf("failed to create report: %w", err)
+		}
+
+		var open int64
+
+		err = tx.Model(&models.Report{}).
+			Where("sound_id = ? AND status = ?", sound.ID, utils.ReportStatusOpen).
+			Count(&open).Error
+		if err != nil {
+			return err
+		}
+
+		if threshold > 0 && open >= int64(threshold) {
+			return tx.Model(&models.Sound{}).Where("id = ?", sound.ID).Update("hidden", true).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// GetGuildReports retrieves the reports in a guild with the given status, oldest first. Reports of trashed sounds are
+// left out until the sound is restored.
+func (db *DB) GetGuildReports(guildID int64, status string) ([]*models.Report, error) {
+	var reports []*models.Report
+
+	err := db.Joins("JOIN sounds ON sounds.id = reports.sound_id AND sounds.deleted_at IS NULL").
+		Where("reports.guild_id = ? AND reports.status = ?", guildID, status).
+		Order("reports.created_at ASC").
+		Find(&reports).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// DecideReport closes every open report of the reported sound in the guild with the given status. Resolving the
+// reports keeps the sound hidden, while dismissing them makes it playable again once no other guild has an open or
+// upheld report of it.
+func (db *DB) DecideReport(guildID int64, reportID, status string, adminID *int64) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var report models.Report
+		err := tx.Joins("JOIN sounds ON sounds.id = reports.sound_id AND sounds.deleted_at IS NULL").
+			Where("reports.id = ? AND reports.guild_id = ?", reportID, guildID).
+			First(&report).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.Report{}).
+			Where("sound_id = ? AND guild_id = ? AND status = ?", report.SoundID, guildID, utils.ReportStatusOpen).
+			Updates(map[string]any{
+				"status":      status,
+				"resolved_by": adminID,
+				"resolved_at": time.Now().UTC(),
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to update reports: %w", err)
+		}
+
+		hidden := status == utils.ReportStatusResolved
+		if !hidden {
+			var remaining int64
+
+			err := tx.Model(&models.Report{}).
+				Where("sound_id = ? AND status IN ?", report.SoundID, []string{utils.ReportStatusOpen, utils.ReportStatusResolved}).
+				Count(&remaining).Error
+			if err != nil {
+				return err
+			}
+
+			hidden = remaining > 0
+		}
+
+		err = tx.Model(&models.Sound{}).
+			Where("id = ?", report.SoundID).
+			Update("hidden", hidden).Error
+		if err != nil {
+			return fmt.Errorf("failed to update sound: %w", err)
+		}
+
+		return tx.Where("id = ?", report.SoundID).First(&sound).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
@@ -0,0 +1,144 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestCreateReport(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID = 1
+
+	tests := []struct {
+		name       string
+		threshold  int
+		reporters  []int64
+		wantHidden bool
+	}{
+		{
+			name:       "Below the threshold",
+			threshold:  2,
+			reporters:  []int64{20},
+			wantHidden: false,
+		},
+		{
+			name:       "Reaching the threshold",
+			threshold:  2,
+			reporters:  []int64{20, 30},
+			wantHidden: true,
+		},
+		{
+			name:       "Disabled threshold",
+			threshold:  0,
+			reporters:  []int64{20, 30, 40},
+			wantHidden: false,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sound := databasetest.CreateSound(t, db, guildID, int64(100+i), "reported.mp3")
+
+			for _, reporterID := range tt.reporters {
+				_, err := db.CreateReport(sound, guildID, reporterID, "offensive", tt.threshold)
+				require.NoError(t, err)
+			}
+
+			_, err := db.CreateReport(sound, guildID, tt.reporters[0], "offensive", tt.threshold)
+			require.ErrorIs(t, err, database.ErrAlreadyReported)
+
+			stored, err := db.GetSoundByID(sound.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHidden, stored.Hidden)
+		})
+	}
+}
+
+func TestDecideReport(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, otherGuildID = 1, 2
+
+	tests := []struct {
+		name           string
+		status         string
+		otherReport    bool
+		wantHidden     bool
+		wantOtherState string
+	}{
+		{
+			name:       "Resolving keeps the sound hidden",
+			status:     utils.ReportStatusResolved,
+			wantHidden: true,
+		},
+		{
+			name:       "Dismissing makes the sound playable",
+			status:     utils.ReportStatusDismissed,
+			wantHidden: false,
+		},
+		{
+			name:           "Dismissing leaves another guild's reports open",
+			status:         utils.ReportStatusDismissed,
+			otherReport:    true,
+			wantHidden:     true,
+			wantOtherState: utils.ReportStatusOpen,
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sound := databasetest.CreateSound(t, db, utils.GlobalGuildID, int64(100+i), "global.mp3")
+
+			report, err := db.CreateReport(sound, guildID, 20, "offensive", 1)
+			require.NoError(t, err)
+
+			var other *models.Report
+			if tt.otherReport {
+				other, err = db.CreateReport(sound, otherGuildID, 30, "offensive", 1)
+				require.NoError(t, err)
+			}
+
+			_, err = db.DecideReport(otherGuildID, report.ID, tt.status, nil)
+			require.Error(t, err, "reports can only be decided in their own guild")
+
+			decided, err := db.DecideReport(guildID, report.ID, tt.status, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHidden, decided.Hidden)
+
+			reports, err := db.GetGuildReports(guildID, tt.status)
+			require.NoError(t, err)
+			assert.Contains(t, reportIDs(reports), report.ID)
+
+			if other != nil {
+				reports, err := db.GetGuildReports(otherGuildID, tt.wantOtherState)
+				require.NoError(t, err)
+				assert.Contains(t, reportIDs(reports), other.ID)
+			}
+		})
+	}
+}
+
+func reportIDs(reports []*models.Report) []string {
+	ids := make([]string, 0, len(reports))
+	for _, report := range reports {
+		ids = append(ids, report.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// scrubColumns are the columns of a sound needed to scrub its file.
+var scrubColumns = []string{"sounds.id", "sounds.internal_filename", "sounds.checksum", "sounds.corrupt"}
+
+// GetSoundsToScrub retrieves up to limit sounds whose file was checked against its checksum longest ago, those never
+// checked first. Trashed sounds are included, as they can still be restored, but broken ones are left out as they
+// have no file to check.
+func (db *DB) GetSoundsToScrub(limit int) ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Select(scrubColumns).
+		Where("broken = ?", false).
+		Order("scrubbed_at IS NOT NULL, scrubbed_at, id").
+		Limit(limit).
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// GetUserSoundsToScrub retrieves the sounds of a Discord user across all guilds to check their files, trashed ones
+// included and broken ones left out as in GetSoundsToScrub.
+func (db *DB) GetUserSoundsToScrub(userID int64) ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Select(scrubColumns).
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.user_id = ? AND sounds.broken = ?", userID, false).
+		Order("sounds.id").
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// RecordScrub records that the file of a sound was checked, whether it was found corrupt, and its checksum if it
+// wasn't known yet. Nothing is recorded if the sound's audio was replaced in the meantime, as the check was of the
+// old file.
+func (db *DB) RecordScrub(sound *models.Sound, checksum string, corrupt bool) error {
+	err := db.Unscoped().Model(&models.Sound{}).
+		Where("id = ? AND internal_filename = ?", sound.ID, sound.InternalFilename).
+		Updates(map[string]any{
+			"checksum":    checksum,
+			"corrupt":     corrupt,
+			"scrubbed_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record scrub: %w", err)
+	}
+
+	return nil
+}
+
+// GetIntegrityReport reports the sounds whose file was found corrupt, along with how much of the stored files has yet
+// to be checked.
+func (db *DB) GetIntegrityReport() (*models.IntegrityReport, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Joins("User").
+		Where("sounds.corrupt = ?", true).
+		Order("sounds.scrubbed_at DESC").
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.IntegrityReport{CorruptSounds: make([]*models.CorruptSound, 0, len(sounds))}
+
+	for _, sound := range sounds {
+		report.CorruptSounds = append(report.CorruptSounds, &models.CorruptSound{
+			Sound:   sound,
+			GuildID: sound.User.GuildID,
+			UserID:  sound.User.UserID,
+		})
+	}
+
+	err = db.Unscoped().Model(&models.Sound{}).
+		Where("broken = ? AND checksum = ''", false).
+		Count(&report.Unverified).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Unscoped().Model(&models.Sound{}).
+		Where("broken = ? AND scrubbed_at IS NULL", false).
+		Count(&report.Unscrubbed).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var lastScrubbed models.Sound
+
+	err = db.Unscoped().Select("scrubbed_at").Where("scrubbed_at IS NOT NULL").Order("scrubbed_at DESC").
+		Limit(1).Find(&lastScrubbed).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report.LastScrubbed = lastScrubbed.ScrubbedAt
+
+	return report, nil
+}
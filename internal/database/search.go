@@ -0,0 +1,152 @@
+package database
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// soundSearchTriggers keep the full-text index in sync with the sounds table. Trashed sounds stay in the index and
+// are filtered out when searching.
+var soundSearchTriggers = map[string]string{
+	"sounds_fts_insert": `CREATE TRIGGER IF NOT EXISTS sounds_fts_insert AFTER INSERT ON sounds BEGIN
+		INSERT INTO sounds_fts (sound_id, display_name, original_name, tags, description)
+		VALUES (new.id, new.display_name, new.original_name, COALESCE(new.tags, ''), new.description);
+	END`,
+	"sounds_fts_update": `CREATE TRIGGER IF NOT EXISTS sounds_fts_update
+	AFTER UPDATE OF display_name, original_name, tags, description ON sounds BEGIN
+		DELETE FROM sounds_fts WHERE sound_id = old.id;
+		INSERT INTO sounds_fts (sound_id, display_name, original_name, tags, description)
+		VALUES (new.id, new.display_name, new.original_name, COALESCE(new.tags, ''), new.description);
+	END`,
+	"sounds_fts_delete": `CREATE TRIGGER IF NOT EXISTS sounds_fts_delete AFTER DELETE ON sounds BEGIN
+		DELETE FROM sounds_fts WHERE sound_id = old.id;
+	END`,
+}
+
+// setupSoundSearch creates the full-text index over sound names, tags and descriptions, along with the triggers that
+// keep it in sync, and rebuilds it. FTS5 is only available when built with the sqlite_fts5 tag. Without it, the
+// triggers are dropped so that writes to sounds keep working, the index is left alone even if an earlier build created
+// it, and it reports that full-text search is unavailable.
+func setupSoundSearch(db *gorm.DB) (bool, error) {
+	var fts5 int64
+
+	err := db.Raw("SELECT COUNT(*) FROM pragma_compile_options WHERE compile_options = 'ENABLE_FTS5'").Scan(&fts5).Error
+	if err != nil {
+		return false, err
+	}
+
+	if fts5 == 0 {
+		for name := range soundSearchTriggers {
+			if err := db.Exec("DROP TRIGGER IF EXISTS " + name).Error; err != nil {
+				return false, err
+			}
+		}
+
+		return false, nil
+	}
+
+	err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS sounds_fts USING fts5(
+		sound_id UNINDEXED, display_name, original_name, tags, description, tokenize = 'unicode61 remove_diacritics 2'
+	)`).Error
+	if err != nil {
+		return false, err
+	}
+
+	// the index may have drifted while running a build without FTS5, so it is rebuilt from scratch on every start
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for _, trigger := range soundSearchTriggers {
+			if err := tx.Exec(trigger).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM sounds_fts").Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(`INSERT INTO sounds_fts (sound_id, display_name, original_name, tags, description)
+			SELECT id, display_name, original_name, COALESCE(tags, ''), description FROM sounds`).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SearchSounds searches the sounds a user can pick in a guild: their own sounds, the guild library and, if
+// includeGlobal is set, their global sounds. Only approved sounds that aren't hidden, broken or corrupt are included.
+// Results are ranked by relevance where full-text search is available, and otherwise listed in the usual order.
+func (db *DB) SearchSounds(guildID, userID int64, query string, includeGlobal bool, limit, offset int) ([]*models.Sound, int64, error) {
+	owners := db.Model(&models.User{}).
+		Select("id").
+		Where("guild_id = ? AND user_id IN ?", guildID, []int64{userID, utils.LibraryUserID})
+	if includeGlobal {
+		owners = owners.Or("guild_id = ? AND user_id = ?", utils.GlobalGuildID, userID)
+	}
+
+	search := db.Model(&models.Sound{}).
+		Where("sounds.user_guild_id IN (?)", owners).
+		Where("sounds.status = ? AND sounds.hidden = ? AND sounds.broken = ? AND sounds.corrupt = ?",
+			utils.SoundStatusApproved, false, false, false)
+
+	terms := strings.Fields(query)
+
+	if db.fullTextSearch {
+		search = search.Joins("JOIN sounds_fts ON sounds_fts.sound_id = sounds.id").
+			Where("sounds_fts MATCH ?", fullTextQuery(terms))
+	} else {
+		for _, term := range terms {
+			pattern := "%" + escapeLike(term) + "%"
+			search = search.Where(`(sounds.display_name LIKE @p ESCAPE '\' OR sounds.original_name LIKE @p ESCAPE '\'
+				OR sounds.tags LIKE @p ESCAPE '\' OR sounds.description LIKE @p ESCAPE '\')`,
+				map[string]any{"p": pattern})
+		}
+	}
+
+	var total int64
+	if err := search.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if db.fullTextSearch {
+		// lower is better, with matches on the display name weighted the highest
+		search = search.Order("bm25(sounds_fts, 0, 10.0, 5.0, 5.0, 1.0)")
+	}
+
+	var sounds []*models.Sound
+
+	err := search.Order("sounds.position ASC, sounds.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&sounds).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.populatePlayCounts(sounds); err != nil {
+		return nil, 0, err
+	}
+
+	return sounds, total, nil
+}
+
+// fullTextQuery turns search terms into an FTS5 query that matches all of them as prefixes. Each term is quoted, so
+// user input can't use the FTS5 query syntax.
+func fullTextQuery(terms []string) string {
+	quoted := make([]string, 0, len(terms))
+	for _, term := range terms {
+		quoted = append(quoted, `"`+strings.ReplaceAll(term, `"`, `""`)+`"*`)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// escapeLike escapes the wildcards in a LIKE pattern, for use with ESCAPE '\'.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
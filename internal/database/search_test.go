@@ -0,0 +1,122 @@
+package database_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestSearchSounds(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, userID = 1, 10
+
+	createSound := func(guildID, userID int64, name, status string, metadata *models.UpdateSoundRequest) string {
+		file := &models.SoundFile{OriginalName: name, InternalFilename: name, MimeType: "audio/mpeg"}
+		sound := databasetest.CreateSoundWithFile(t, db, guildID, userID, file, status)
+
+		if metadata != nil {
+			_, err := db.UpdateSoundMetadata(sound.ID, metadata)
+			require.NoError(t, err)
+		}
+
+		return sound.ID
+	}
+
+	fanfare := "Victory fanfare"
+	own := createSound(guildID, userID, "final_v2.mp3", utils.SoundStatusApproved, &models.UpdateSoundRequest{DisplayName: &fanfare})
+	tagged := createSound(guildID, userID, "horn.mp3", utils.SoundStatusApproved, &models.UpdateSoundRequest{Tags: &[]string{"victory"}})
+	library := createSound(guildID, utils.LibraryUserID, "victory_library.mp3", utils.SoundStatusApproved, nil)
+	global := createSound(utils.GlobalGuildID, userID, "victory_global.mp3", utils.SoundStatusApproved, nil)
+
+	createSound(guildID, userID, "victory_pending.mp3", utils.SoundStatusPending, nil)
+	createSound(guildID, 20, "victory_other_user.mp3", utils.SoundStatusApproved, nil)
+	createSound(2, userID, "victory_other_guild.mp3", utils.SoundStatusApproved, nil)
+
+	corrupt := databasetest.CreateSound(t, db, guildID, userID, "victory_corrupt.mp3")
+	require.NoError(t, db.RecordScrub(corrupt, "checksum", true))
+
+	tests := []struct {
+		name          string
+		query         string
+		includeGlobal bool
+		want          []string
+	}{
+		{
+			name:          "Only sounds the user can pick",
+			query:         "victory",
+			includeGlobal: true,
+			want:          []string{own, tagged, library, global},
+		},
+		{
+			name:  "Without global sounds",
+			query: "victory",
+			want:  []string{own, tagged, library},
+		},
+		{
+			name:  "All terms must match",
+			query: "victory fanfare",
+			want:  []string{own},
+		},
+		{
+			name:  "No matches",
+			query: "trombone",
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sounds, total, err := db.SearchSounds(guildID, userID, tt.query, tt.includeGlobal, utils.MaxPageSize, 0)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(tt.want)), total)
+
+			ids := make([]string, 0, len(sounds))
+			for _, sound := range sounds {
+				ids = append(ids, sound.ID)
+			}
+
+			assert.ElementsMatch(t, tt.want, ids)
+		})
+	}
+}
+
+func TestSearchSoundsWithoutFullTextSearch(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := database.NewSQLiteDB(path)
+	require.NoError(t, err)
+
+	sound := databasetest.CreateSound(t, db, 1, 10, "horn.mp3")
+
+	sqlDB, err := db.DB.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	// opening without migrating leaves full-text search off, as in builds without FTS5
+	db, err = database.OpenSQLiteDB(path)
+	require.NoError(t, err)
+
+	sounds, total, err := db.SearchSounds(1, 10, "hor", false, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, sounds, 1)
+	assert.Equal(t, sound.ID, sounds[0].ID)
+
+	_, _, err = db.TrashSound(sound.ID)
+	require.NoError(t, err)
+	require.NoError(t, db.PurgeSound(sound.ID))
+}
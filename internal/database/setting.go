@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
 // UpdateUserSetting updates user settings.
@@ -31,26 +32,35 @@ func (db *DB) UpdateUserSetting(userID string, req *models.UpdateSettingsRequest
 	return setting, nil
 }
 
-// GetOrCreateUserSetting retrieves or creates user settings if they don't already exist.
-func (db *DB) GetOrCreateUserSetting(guildID, userID int64) (*models.Setting, error) {
-	user, err := db.CreateOrGetUser(guildID, userID)
+// GetUserSetting retrieves user settings without creating them, and where they came from. Users without settings in
+// the guild get their global settings, or otherwise the guild's defaults, which are not persisted.
+func (db *DB) GetUserSetting(guildID, userID int64) (*models.Setting, string, error) {
+	setting, err := db.findSetting(guildID, userID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	setting, err := db.getCreateSettings(user.ID)
-	if err != nil {
-		return nil, err
+	if setting != nil {
+		return setting, utils.SettingSourceGuild, nil
+	}
+
+	if guildID != utils.GlobalGuildID {
+		setting, err = db.findSetting(utils.GlobalGuildID, userID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if setting != nil {
+			return setting, utils.SettingSourceGlobal, nil
+		}
 	}
 
-	err = db.
-		Where("user_guild_id = ?", user.ID).
-		First(setting).Error
+	guildSetting, err := db.GetGuildSetting(guildID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return setting, nil
+	return &models.Setting{Mode: guildSetting.DefaultMode}, utils.SettingSourceDefault, nil
 }
 
 func (db *DB) getCreateSettings(userGuildID string) (*models.Setting, error) {
@@ -59,8 +69,19 @@ func (db *DB) getCreateSettings(userGuildID string) (*models.Setting, error) {
 	err := db.Where("user_guild_id = ?", userGuildID).First(&setting).Error
 
 	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err := db.GetUserByUserGuildID(userGuildID)
+		if err != nil {
+			return nil, err
+		}
+
+		guildSetting, err := db.GetGuildSetting(user.GuildID)
+		if err != nil {
+			return nil, err
+		}
+
 		newSetting := &models.Setting{
 			UserGuildID: userGuildID,
+			Mode:        guildSetting.DefaultMode,
 		}
 
 		if err := db.Create(newSetting).Error; err != nil {
@@ -0,0 +1,105 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// ErrShareTokenInvalid is returned when redeeming a share token that doesn't exist, has expired or has been used up.
+var ErrShareTokenInvalid = errors.New("share token is invalid or has expired")
+
+// CreateShareToken creates a share token for a sound.
+func (db *DB) CreateShareToken(soundID string, createdBy int64, req *models.CreateShareRequest) (*models.ShareToken, error) {
+	token, err := gonanoid.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	share := models.ShareToken{
+		Token:     token,
+		SoundID:   soundID,
+		CreatedBy: createdBy,
+		SingleUse: req.SingleUse,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if req.ExpiresAt != nil {
+		expiresAt := req.ExpiresAt.UTC()
+		share.ExpiresAt = &expiresAt
+	}
+
+	if err := db.Create(&share).Error; err != nil {
+		return nil, fmt.Errorf("failed to create share token: %w", err)
+	}
+
+	return &share, nil
+}
+
+// GetShareTokens retrieves the share tokens of a sound, newest first.
+func (db *DB) GetShareTokens(soundID string) ([]*models.ShareToken, error) {
+	var shares []*models.ShareToken
+
+	err := db.Where("sound_id = ?", soundID).Order("created_at DESC").Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+// RevokeShareToken deletes a share token of a sound, returning gorm.ErrRecordNotFound if there is no such token.
+func (db *DB) RevokeShareToken(soundID, token string) error {
+	result := db.Where("sound_id = ? AND token = ?", soundID, token).Delete(&models.ShareToken{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke share token: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// ClaimShareToken records a use of a share token, provided it is still valid. Single-use tokens can only be claimed
+// once, even by concurrent requests, and tokens of trashed sounds can't be claimed until the sound is restored.
+func (db *DB) ClaimShareToken(token string) (*models.ShareToken, error) {
+	result := db.Model(&models.ShareToken{}).
+		Where("token = ?", token).
+		Where("sound_id IN (?)", db.Model(&models.Sound{}).Select("id")).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Where("single_use = ? OR uses = 0", false).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to claim share token: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrShareTokenInvalid
+	}
+
+	var share models.ShareToken
+	if err := db.Where("token = ?", token).First(&share).Error; err != nil {
+		return nil, err
+	}
+
+	return &share, nil
+}
+
+// ReleaseShareToken undoes a claim of a share token, when the sound couldn't be cloned after all.
+func (db *DB) ReleaseShareToken(token string) error {
+	err := db.Model(&models.ShareToken{}).
+		Where("token = ? AND uses > 0", token).
+		Update("uses", gorm.Expr("uses - 1")).Error
+	if err != nil {
+		return fmt.Errorf("failed to release share token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+func TestClaimShareToken(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	sound := databasetest.CreateSound(t, db, 1, 10, "shared.mp3")
+
+	expired := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		req       *models.CreateShareRequest
+		wantValid []bool
+	}{
+		{
+			name:      "Reusable token",
+			req:       &models.CreateShareRequest{},
+			wantValid: []bool{true, true},
+		},
+		{
+			name:      "Single-use token",
+			req:       &models.CreateShareRequest{SingleUse: true},
+			wantValid: []bool{true, false},
+		},
+		{
+			name:      "Expired token",
+			req:       &models.CreateShareRequest{ExpiresAt: &expired},
+			wantValid: []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			share, err := db.CreateShareToken(sound.ID, 10, tt.req)
+			require.NoError(t, err)
+
+			for _, wantValid := range tt.wantValid {
+				claimed, err := db.ClaimShareToken(share.Token)
+				if wantValid {
+					require.NoError(t, err)
+					assert.Equal(t, sound.ID, claimed.SoundID)
+				} else {
+					assert.ErrorIs(t, err, database.ErrShareTokenInvalid)
+				}
+			}
+		})
+	}
+}
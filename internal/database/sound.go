@@ -9,29 +9,123 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
-// CreateSound creates a new sound record.
-func (db *DB) CreateSound(userGuildID, originalName, internalFilename, mimeType string) (*models.Sound, error) {
+// CreateSound creates a new sound record for the given file with the given review status. It fails with
+// ErrUserStorageExceeded or ErrGuildStorageExceeded if the sound doesn't fit in the quota.
+func (db *DB) CreateSound(userGuildID string, file *models.SoundFile, status string, quota *models.StorageQuota) (*models.Sound, error) {
+	return db.createSound(newSound(userGuildID, file, status), quota)
+}
+
+// CreateSoundWithMetadata creates a new sound record for the given file with the given review status and metadata,
+// e.g. for a sound imported along with a manifest. The quota is checked as in CreateSound.
+func (db *DB) CreateSoundWithMetadata(userGuildID string, file *models.SoundFile, status string, req *models.UpdateSoundRequest, quota *models.StorageQuota) (*models.Sound, error) {
+	sound := newSound(userGuildID, file, status)
+
+	applySoundMetadata(sound, req)
+
+	return db.createSound(sound, quota)
+}
+
+// newSound builds the record of a new sound for the given file.
+func newSound(userGuildID string, file *models.SoundFile, status string) *models.Sound {
+	return &models.Sound{
+		UserGuildID:      userGuildID,
+		OriginalName:     file.OriginalName,
+		InternalFilename: file.InternalFilename,
+		MimeType:         file.MimeType,
+		Size:             file.Size,
+		Checksum:         file.Checksum,
+		Status:           status,
+	}
+}
+
+// CloneSound creates a new sound record for a copy of the source sound and its metadata, stored under the given
+// internal filename with the given size. The copy has the same checksum as the source. The quota is checked as in
+// CreateSound.
+func (db *DB) CloneSound(source *models.Sound, userGuildID, internalFilename, status string, size int64, quota *models.StorageQuota) (*models.Sound, error) {
+	return db.createSound(&models.Sound{
+		UserGuildID:      userGuildID,
+		OriginalName:     source.OriginalName,
+		DisplayName:      source.DisplayName,
+		Emoji:            source.Emoji,
+		Tags:             source.Tags,
+		Description:      source.Description,
+		InternalFilename: internalFilename,
+		MimeType:         source.MimeType,
+		Size:             size,
+		Checksum:         source.Checksum,
+		Status:           status,
+		ClonedFromID:     &source.ID,
+	}, quota)
+}
+
+func (db *DB) createSound(sound *models.Sound, quota *models.StorageQuota) (*models.Sound, error) {
 	id, err := gonanoid.New()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate ID: %w", err)
 	}
 
-	sound := models.Sound{
-		ID:               id,
-		UserGuildID:      userGuildID,
-		OriginalName:     originalName,
-		InternalFilename: internalFilename,
-		MimeType:         mimeType,
-		CreatedAt:        time.Now().UTC(),
+	sound.ID = id
+	sound.CreatedAt = time.Now().UTC()
+
+	if sound.Tags == nil {
+		sound.Tags = []string{}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := checkStorageQuota(tx, sound.UserGuildID, "", sound.Size, quota); err != nil {
+			return err
+		}
+
+		if err := tx.Create(sound).Error; err != nil {
+			return fmt.Errorf("failed to create sound: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := db.Create(&sound).Error; err != nil {
-		return nil, fmt.Errorf("failed to create sound: %w", err)
+	return sound, nil
+}
+
+// UpdateSoundMetadata updates the metadata of a sound. Fields that are not in the request are left unchanged.
+func (db *DB) UpdateSoundMetadata(id string, req *models.UpdateSoundRequest) (*models.Sound, error) {
+	sound, err := db.GetSoundByID(id)
+	if err != nil {
+		return nil, err
 	}
 
-	return &sound, nil
+	applySoundMetadata(sound, req)
+
+	err = db.Model(sound).Select("display_name", "emoji", "tags", "description").Updates(sound).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update sound: %w", err)
+	}
+
+	return sound, nil
+}
+
+// applySoundMetadata sets the metadata fields that are in the request on the sound.
+func applySoundMetadata(sound *models.Sound, req *models.UpdateSoundRequest) {
+	if req.DisplayName != nil {
+		sound.DisplayName = *req.DisplayName
+	}
+
+	if req.Emoji != nil {
+		sound.Emoji = *req.Emoji
+	}
+
+	if req.Tags != nil {
+		sound.Tags = *req.Tags
+	}
+
+	if req.Description != nil {
+		sound.Description = *req.Description
+	}
 }
 
 // GetSoundByID retrieves a sound by ID with user relationship.
@@ -46,51 +140,297 @@ func (db *DB) GetSoundByID(id string) (*models.Sound, error) {
 	return &sound, nil
 }
 
-// DeleteSound deletes a sound, updates the active sound if necessary, and returns the new active sound, if any.
-func (db *DB) DeleteSound(id string) (deletedSound, newSound *models.Sound, err error) {
-	tx := db.Begin()
+// TrashSound moves a sound to the trash, where it is kept with its file and versions until it is restored or purged.
+// It updates the active sound if necessary, and returns the new active sound, if any.
+func (db *DB) TrashSound(id string) (deletedSound, newSound *models.Sound, err error) {
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&deletedSound).Error; err != nil {
+			return err
+		}
+
+		replacements, err := trashSounds(tx, []string{deletedSound.ID})
+		if err != nil {
+			return err
+		}
 
-	defer tx.Rollback()
+		newSound = replacements[deletedSound.UserGuildID]
 
-	if err := tx.Where("id = ?", id).First(&deletedSound).Error; err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, nil, err
 	}
 
-	var setting models.Setting
+	return deletedSound, newSound, nil
+}
 
-	err = tx.Where("active_sound_id = ?", deletedSound.ID).First(&setting).Error
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, nil, err
+// BatchUpdateSounds moves the sounds to delete to the trash and reorders the user's sounds in a single transaction.
+// Sounds in order get their position from their index in it. It returns the user's new active sound, if it changed.
+func (db *DB) BatchUpdateSounds(userGuildID string, deleteIDs, order []string) (*models.Sound, error) {
+	var newSound *models.Sound
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(deleteIDs) > 0 {
+			replacements, err := trashSounds(tx, deleteIDs)
+			if err != nil {
+				return err
+			}
+
+			newSound = replacements[userGuildID]
+		}
+
+		for i, id := range order {
+			err := tx.Model(&models.Sound{}).
+				Where("id = ? AND user_guild_id = ?", id, userGuildID).
+				Update("position", i+1).Error
+			if err != nil {
+				return fmt.Errorf("failed to reorder sounds: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newSound, nil
+}
+
+// GetSoundsByIDs retrieves those of the given sounds that belong to the user.
+func (db *DB) GetSoundsByIDs(userGuildID string, ids []string) ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Where("user_guild_id = ? AND id IN ?", userGuildID, ids).Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// trashSounds soft deletes the given sounds and stops them being the guild default. Their versions, reports, share
+// tokens and places in default sound pools are kept for when they are restored, and ignored while they are trashed.
+// Once all of them are trashed, a replacement is picked for every setting that had one of them active. It returns
+// the replacements by user, nil where no other sound was available.
+func trashSounds(tx *gorm.DB, ids []string) (map[string]*models.Sound, error) {
+	// the sounds can no longer be guild defaults
+	err := tx.Model(&models.GuildSetting{}).
+		Where("default_sound_id IN ?", ids).
+		Update("default_sound_id", nil).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// soft delete, see models.Sound.DeletedAt
+	if err := tx.Where("id IN ?", ids).Delete(&models.Sound{}).Error; err != nil {
+		return nil, err
+	}
+
+	// library sounds can be active for many users at once
+	var settings []*models.Setting
+	if err := tx.Where("active_sound_id IN ?", ids).Find(&settings).Error; err != nil {
+		return nil, err
 	}
 
-	// if found, pick a replacement or clear it
-	if err == nil {
-		err = tx.Where("user_guild_id = ? AND id <> ?", deletedSound.UserGuildID, deletedSound.ID).
-			Order("created_at desc").
-			First(&newSound).Error
+	replacements := make(map[string]*models.Sound, len(settings))
 
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, err
+	// pick a replacement from the user's own sounds or clear it
+	for _, setting := range settings {
+		replacement, err := findReplacementSound(tx, setting.UserGuildID)
+		if err != nil {
+			return nil, err
 		}
 
-		if err == nil {
-			setting.ActiveSoundID = &newSound.ID
+		if replacement != nil {
+			setting.ActiveSoundID = &replacement.ID
 		} else {
 			setting.ActiveSoundID = nil // no other sound available
 		}
 
-		if err := tx.Save(&setting).Error; err != nil {
-			return nil, nil, err
+		if err := tx.Save(setting).Error; err != nil {
+			return nil, err
 		}
+
+		replacements[setting.UserGuildID] = replacement
 	}
 
-	if err := tx.Delete(&deletedSound).Error; err != nil {
-		return nil, nil, err
+	return replacements, nil
+}
+
+// RestoreSound takes a sound out of the trash. The quota is checked as in CreateSound.
+func (db *DB) RestoreSound(id string, quota *models.StorageQuota) (*models.Sound, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var sound models.Sound
+
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&sound).Error; err != nil {
+			return err
+		}
+
+		if err := checkStorageQuota(tx, sound.UserGuildID, sound.ID, sound.Size, quota); err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Model(&sound).Update("deleted_at", nil).Error
+		if err != nil {
+			return fmt.Errorf("failed to restore sound: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		return nil, nil, err
+	return db.GetSoundByID(id)
+}
+
+// GetSoundIncludingTrashed retrieves a sound by ID, whether or not it is in the trash.
+func (db *DB) GetSoundIncludingTrashed(id string) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := db.Unscoped().Where("id = ?", id).First(&sound).Error
+	if err != nil {
+		return nil, err
 	}
 
-	return deletedSound, newSound, nil
+	return &sound, nil
+}
+
+// GetTrashedSounds retrieves the sounds of a user in a guild that are in the trash, most recently deleted first.
+func (db *DB) GetTrashedSounds(guildID, userID int64) ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.guild_id = ? AND users.user_id = ?", guildID, userID).
+		Where("sounds.deleted_at IS NOT NULL").
+		Order("sounds.deleted_at DESC").
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// GetExpiredTrashedSounds retrieves the sounds that were moved to the trash before the given time, along with their
+// versions.
+func (db *DB) GetExpiredTrashedSounds(before time.Time) ([]*models.Sound, error) {
+	var sounds []*models.Sound
+
+	err := db.Unscoped().
+		Preload("Versions").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before.UTC()).
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// PurgeSound permanently deletes a trashed sound and everything that refers to it, and schedules the removal of its
+// files, which the caller should then remove.
+func (db *DB) PurgeSound(id string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		filenames, err := soundFilenames(tx, []string{id})
+		if err != nil {
+			return fmt.Errorf("failed to purge sound: %w", err)
+		}
+
+		if err := scheduleFileRemovals(tx, filenames, time.Now()); err != nil {
+			return err
+		}
+
+		for _, model := range []any{&models.SoundVersion{}, &models.Report{}, &models.ShareToken{}, &models.GuildDefaultSound{}} {
+			if err := tx.Where("sound_id = ?", id).Delete(model).Error; err != nil {
+				return fmt.Errorf("failed to purge sound: %w", err)
+			}
+		}
+
+		err = tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&models.Sound{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to purge sound: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// findReplacementSound picks the first approved sound of a user, in the order they are listed. Trashed, hidden, broken
+// and corrupt sounds are never picked.
+func findReplacementSound(tx *gorm.DB, userGuildID string) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := tx.Where("user_guild_id = ? AND status = ? AND hidden = ? AND broken = ? AND corrupt = ?",
+		userGuildID, utils.SoundStatusApproved, false, false, false).
+		Order("position ASC, created_at DESC").
+		First(&sound).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil // no replacement available
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
+
+// GetSoundInGuild retrieves a sound by ID, provided it belongs to a user of the given guild.
+func (db *DB) GetSoundInGuild(guildID int64, id string) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := db.Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("sounds.id = ? AND users.guild_id = ?", id, guildID).
+		First(&sound).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
+
+// GetModerationQueue retrieves the sounds in a guild with the given review status, oldest first. Hidden sounds are
+// left to the reports that hid them.
+func (db *DB) GetModerationQueue(guildID int64, status string) ([]*models.ModerationItem, error) {
+	var sounds []*models.Sound
+
+	err := db.Joins("User").
+		Where("User.guild_id = ? AND sounds.status = ? AND sounds.hidden = ? AND sounds.broken = ?", guildID, status, false, false).
+		Order("sounds.created_at ASC").
+		Find(&sounds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*models.ModerationItem, 0, len(sounds))
+	for _, sound := range sounds {
+		items = append(items, &models.ModerationItem{
+			Sound:  sound,
+			UserID: sound.User.UserID,
+		})
+	}
+
+	return items, nil
+}
+
+// ReviewSound records a guild admin's decision on a sound.
+func (db *DB) ReviewSound(id, status string, reason *string, reviewerID *int64) (*models.Sound, error) {
+	sound, err := db.GetSoundByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	sound.Status = status
+	sound.ReviewReason = reason
+	sound.ReviewedBy = reviewerID
+	sound.ReviewedAt = &now
+
+	if err := db.Save(sound).Error; err != nil {
+		return nil, fmt.Errorf("failed to review sound: %w", err)
+	}
+
+	return sound, nil
 }
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+var (
+	// ErrUserStorageExceeded is returned when a sound would take its owner over their storage quota.
+	ErrUserStorageExceeded = errors.New("user storage quota exceeded")
+	// ErrGuildStorageExceeded is returned when a sound would take its owner's guild over its storage quota.
+	ErrGuildStorageExceeded = errors.New("guild storage quota exceeded")
+)
+
+// GetUserStorageUsage retrieves the total size of the sounds owned by a user in a guild, without their global sounds.
+// Sounds in the trash are only included if includeTrashed is set.
+func (db *DB) GetUserStorageUsage(guildID, userID int64, includeTrashed bool) (int64, error) {
+	var used int64
+
+	err := soundSizes(db.DB, includeTrashed).
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.guild_id = ? AND users.user_id = ?", guildID, userID).
+		Scan(&used).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return used, nil
+}
+
+// GetGuildStorageUsage retrieves the total size of the sounds in a guild, and the size of each user's sounds there,
+// largest first. Sounds in the trash are only included if includeTrashed is set.
+func (db *DB) GetGuildStorageUsage(guildID int64, includeTrashed bool) (int64, []*models.UserStorageUsage, error) {
+	users := make([]*models.UserStorageUsage, 0)
+
+	query := db.Model(&models.Sound{})
+	if includeTrashed {
+		query = query.Unscoped()
+	}
+
+	err := query.
+		Select("users.user_id, COUNT(*) AS sounds, COALESCE(SUM(sounds.size), 0) AS used").
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.guild_id = ?", guildID).
+		Group("users.user_id").
+		Order("used DESC, users.user_id ASC").
+		Scan(&users).Error
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var used int64
+	for _, user := range users {
+		used += user.Used
+	}
+
+	return used, users, nil
+}
+
+// checkStorageQuota fails with ErrUserStorageExceeded or ErrGuildStorageExceeded if a sound of the given size would
+// take the user, or their guild, over the quota. The quota covers the current audio of sounds only, as previous
+// versions are kept for a limited time to allow rollbacks. The sound with ID replacedID, if any, is left out of the
+// usage, as its size is being replaced. A nil quota is never exceeded.
+func checkStorageQuota(tx *gorm.DB, userGuildID, replacedID string, size int64, quota *models.StorageQuota) error {
+	if quota == nil {
+		return nil
+	}
+
+	if quota.MaxUserStorage >= 0 {
+		var used int64
+
+		err := soundSizes(tx, quota.IncludeTrashed).
+			Where("sounds.user_guild_id = ? AND sounds.id <> ?", userGuildID, replacedID).
+			Scan(&used).Error
+		if err != nil {
+			return err
+		}
+
+		if used+size > quota.MaxUserStorage {
+			return ErrUserStorageExceeded
+		}
+	}
+
+	if quota.MaxGuildStorage >= 0 {
+		var used int64
+
+		guildID := tx.Model(&models.User{}).Select("guild_id").Where("id = ?", userGuildID)
+
+		err := soundSizes(tx, quota.IncludeTrashed).
+			Joins("JOIN users ON users.id = sounds.user_guild_id").
+			Where("users.guild_id = (?) AND sounds.id <> ?", guildID, replacedID).
+			Scan(&used).Error
+		if err != nil {
+			return err
+		}
+
+		if used+size > quota.MaxGuildStorage {
+			return ErrGuildStorageExceeded
+		}
+	}
+
+	return nil
+}
+
+// soundSizes starts a query for the total size of the sounds it matches. Broken sounds are included, as they take up
+// storage again if their files are recovered.
+func soundSizes(tx *gorm.DB, includeTrashed bool) *gorm.DB {
+	query := tx.Model(&models.Sound{})
+	if includeTrashed {
+		query = query.Unscoped()
+	}
+
+	return query.Select("COALESCE(SUM(sounds.size), 0)")
+}
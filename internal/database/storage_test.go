@@ -0,0 +1,94 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestStorageQuota(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID = 1
+
+	alice := databasetest.CreateUser(t, db, guildID, 10)
+	bob := databasetest.CreateUser(t, db, guildID, 20)
+
+	quota := &models.StorageQuota{MaxUserStorage: 100, MaxGuildStorage: 150}
+
+	first, err := db.CreateSound(alice.ID, &models.SoundFile{OriginalName: "a.mp3", InternalFilename: "a.mp3", MimeType: "audio/mpeg", Size: 60}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.CreateSound(alice.ID, &models.SoundFile{OriginalName: "b.mp3", InternalFilename: "b.mp3", MimeType: "audio/mpeg", Size: 50}, utils.SoundStatusApproved, quota)
+	require.ErrorIs(t, err, database.ErrUserStorageExceeded)
+
+	_, err = db.CreateSound(bob.ID, &models.SoundFile{OriginalName: "c.mp3", InternalFilename: "c.mp3", MimeType: "audio/mpeg", Size: 80}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.CreateSound(bob.ID, &models.SoundFile{OriginalName: "d.mp3", InternalFilename: "d.mp3", MimeType: "audio/mpeg", Size: 20}, utils.SoundStatusApproved, quota)
+	require.ErrorIs(t, err, database.ErrGuildStorageExceeded)
+
+	// the replaced audio no longer counts
+	_, err = db.ReplaceSoundFile(first.ID, &models.SoundFile{OriginalName: "e.mp3", InternalFilename: "e.mp3", MimeType: "audio/mpeg", Size: 70}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	used, err := db.GetUserStorageUsage(guildID, 10, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(70), used)
+
+	// trashed sounds only take up room if they count towards the quota
+	_, _, err = db.TrashSound(first.ID)
+	require.NoError(t, err)
+
+	_, err = db.CreateSound(alice.ID, &models.SoundFile{OriginalName: "f.mp3", InternalFilename: "f.mp3", MimeType: "audio/mpeg", Size: 60}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.RestoreSound(first.ID, quota)
+	require.ErrorIs(t, err, database.ErrUserStorageExceeded)
+
+	used, err = db.GetUserStorageUsage(guildID, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(130), used)
+
+	used, users, err := db.GetGuildStorageUsage(guildID, false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(140), used)
+	assert.Equal(t, []*models.UserStorageUsage{
+		{UserID: 20, Sounds: 1, Used: 80},
+		{UserID: 10, Sounds: 1, Used: 60},
+	}, users)
+
+	// broken sounds still take up room, as their files may be recovered
+	require.NoError(t, db.SetSoundsBroken([]string{first.ID}, true))
+
+	used, err = db.GetUserStorageUsage(guildID, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(130), used)
+
+	count, err := db.CountSoundsByUser(guildID, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// rolling back to a larger version counts its size in place of the current one
+	carol := databasetest.CreateUser(t, db, 2, 30)
+
+	large, err := db.CreateSound(carol.ID, &models.SoundFile{OriginalName: "g.mp3", InternalFilename: "g.mp3", MimeType: "audio/mpeg", Size: 90}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.ReplaceSoundFile(large.ID, &models.SoundFile{OriginalName: "h.mp3", InternalFilename: "h.mp3", MimeType: "audio/mpeg", Size: 10}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.CreateSound(carol.ID, &models.SoundFile{OriginalName: "i.mp3", InternalFilename: "i.mp3", MimeType: "audio/mpeg", Size: 50}, utils.SoundStatusApproved, quota)
+	require.NoError(t, err)
+
+	_, err = db.RollbackSound(large.ID, 1, utils.SoundStatusApproved, quota)
+	require.ErrorIs(t, err, database.ErrUserStorageExceeded)
+}
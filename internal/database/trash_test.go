@@ -0,0 +1,137 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestTrashSound(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, userID = 1, 10
+
+	sound := databasetest.CreateSound(t, db, guildID, userID, "trashed.mp3")
+
+	_, _, err := db.TrashSound(sound.ID)
+	require.NoError(t, err)
+
+	sounds, err := db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	assert.Empty(t, sounds)
+
+	_, err = db.GetSoundByID(sound.ID)
+	require.Error(t, err)
+
+	count, err := db.CountSoundsByUser(guildID, userID, false)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = db.CountSoundsByUser(guildID, userID, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	trashed, err := db.GetTrashedSounds(guildID, userID)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.True(t, trashed[0].DeletedAt.Valid)
+
+	restored, err := db.RestoreSound(sound.ID, nil)
+	require.NoError(t, err)
+	assert.False(t, restored.DeletedAt.Valid)
+
+	_, _, err = db.TrashSound(sound.ID)
+	require.NoError(t, err)
+
+	expired, err := db.GetExpiredTrashedSounds(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+
+	expired, err = db.GetExpiredTrashedSounds(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+
+	require.NoError(t, db.PurgeSound(sound.ID))
+
+	_, err = db.GetSoundIncludingTrashed(sound.ID)
+	require.Error(t, err)
+}
+
+func TestTrashActiveSoundSkipsUnplayableReplacements(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, userID = 1, 10
+
+	active := databasetest.CreateSound(t, db, guildID, userID, "active.mp3")
+	corrupt := databasetest.CreateSound(t, db, guildID, userID, "corrupt.mp3")
+	hidden := databasetest.CreateSound(t, db, guildID, userID, "hidden.mp3")
+
+	require.NoError(t, db.RecordScrub(corrupt, "checksum", true))
+
+	_, err := db.CreateReport(hidden, guildID, 20, "offensive", 1)
+	require.NoError(t, err)
+
+	_, err = db.UpdateUserSetting(active.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &active.ID})
+	require.NoError(t, err)
+
+	_, _, err = db.TrashSound(active.ID)
+	require.NoError(t, err)
+
+	setting, _, err := db.GetUserSetting(guildID, userID)
+	require.NoError(t, err)
+	assert.Nil(t, setting.ActiveSoundID)
+}
+
+func TestRestoreSoundKeepsReportsAndShareTokens(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID = 1
+
+	sound := databasetest.CreateSound(t, db, guildID, 10, "reported.mp3")
+
+	report, err := db.CreateReport(sound, guildID, 20, "offensive", 1)
+	require.NoError(t, err)
+
+	share, err := db.CreateShareToken(sound.ID, 10, &models.CreateShareRequest{})
+	require.NoError(t, err)
+
+	_, _, err = db.TrashSound(sound.ID)
+	require.NoError(t, err)
+
+	// both are ignored while the sound is trashed
+	reports, err := db.GetGuildReports(guildID, utils.ReportStatusOpen)
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+
+	_, err = db.ClaimShareToken(share.Token)
+	require.ErrorIs(t, err, database.ErrShareTokenInvalid)
+
+	restored, err := db.RestoreSound(sound.ID, nil)
+	require.NoError(t, err)
+	assert.True(t, restored.Hidden)
+
+	reports, err = db.GetGuildReports(guildID, utils.ReportStatusOpen)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, report.ID, reports[0].ID)
+
+	_, err = db.ClaimShareToken(share.Token)
+	require.NoError(t, err)
+
+	dismissed, err := db.DecideReport(guildID, report.ID, utils.ReportStatusDismissed, nil)
+	require.NoError(t, err)
+	assert.False(t, dismissed.Hidden)
+}
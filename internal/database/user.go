@@ -8,6 +8,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
 // CreateOrGetUser creates a new user or returns existing one.
@@ -38,6 +39,20 @@ func (db *DB) CreateOrGetUser(guildID, userID int64) (*models.User, error) {
 	return &user, nil
 }
 
+// findUser retrieves a user's record in a guild without creating it, if they have one.
+func (db *DB) findUser(guildID, userID int64) (*models.User, error) {
+	var user models.User
+
+	err := db.Where("guild_id = ? AND user_id = ?", guildID, userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil //nolint:nilnil // no record
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
 // GetUserByUserGuildID retrieves a user by their userGuildID.
 func (db *DB) GetUserByUserGuildID(id string) (*models.User, error) {
 	var user models.User
@@ -50,21 +65,116 @@ func (db *DB) GetUserByUserGuildID(id string) (*models.User, error) {
 	return &user, nil
 }
 
-// GetSoundsByUser retrieves all sounds for a specific user.
+// GetSoundScope determines how a user may access a sound: as their own, from their guild's library, or from their
+// global library. An empty scope means the sound is not available to the user.
+func (db *DB) GetSoundScope(user *models.User, sound *models.Sound) (string, error) {
+	if sound.UserGuildID == user.ID {
+		return utils.SoundScopeUser, nil
+	}
+
+	owner, err := db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case owner.GuildID == user.GuildID && owner.UserID == utils.LibraryUserID:
+		return utils.SoundScopeLibrary, nil
+	case owner.GuildID == utils.GlobalGuildID && owner.UserID == user.UserID:
+		return utils.SoundScopeGlobal, nil
+	default:
+		return "", nil
+	}
+}
+
+// GetSoundsByUser retrieves all sounds for a specific user. When looking up a guild, the user's global sounds are
+// included after their guild sounds. Users that haven't been seen in the guild have no sounds there, and aren't
+// created by the lookup.
 func (db *DB) GetSoundsByUser(guildID, userID int64) ([]*models.Sound, error) {
-	user, err := db.CreateOrGetUser(guildID, userID)
+	user, err := db.findUser(guildID, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	sounds := make([]*models.Sound, 0)
+
+	if user != nil {
+		err = db.Where("user_guild_id = ? AND broken = ?", user.ID, false).
+			Order("position ASC, created_at DESC").
+			Find(&sounds).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sound := range sounds {
+		sound.Scope = scopeOf(guildID, userID)
+	}
+
+	if guildID != utils.GlobalGuildID && userID != utils.LibraryUserID {
+		globalSounds, err := db.getGlobalSounds(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		sounds = append(sounds, globalSounds...)
+	}
+
+	if err := db.populatePlayCounts(sounds); err != nil {
+		return nil, err
+	}
+
+	return sounds, nil
+}
+
+// CountSoundsByUser counts the sounds owned by a user in a guild, without their global sounds. Sounds in the trash
+// are only counted if includeTrashed is set. Broken sounds are counted, even though they are hidden from the user, as
+// they come back if their files are recovered.
+func (db *DB) CountSoundsByUser(guildID, userID int64, includeTrashed bool) (int64, error) {
+	var count int64
+
+	query := db.DB
+	if includeTrashed {
+		query = query.Unscoped()
+	}
+
+	err := query.Model(&models.Sound{}).
+		Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.guild_id = ? AND users.user_id = ?", guildID, userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count sounds: %w", err)
+	}
+
+	return count, nil
+}
+
+func (db *DB) getGlobalSounds(userID int64) ([]*models.Sound, error) {
 	var sounds []*models.Sound
 
-	err = db.Where("user_guild_id = ?", user.ID).
-		Order("created_at DESC").
+	err := db.Joins("JOIN users ON users.id = sounds.user_guild_id").
+		Where("users.guild_id = ? AND users.user_id = ?", utils.GlobalGuildID, userID).
+		Where("sounds.broken = ?", false).
+		Order("sounds.position ASC, sounds.created_at DESC").
 		Find(&sounds).Error
 	if err != nil {
 		return nil, err
 	}
 
+	for _, sound := range sounds {
+		sound.Scope = utils.SoundScopeGlobal
+	}
+
 	return sounds, nil
 }
+
+func scopeOf(guildID, userID int64) string {
+	switch {
+	case guildID == utils.GlobalGuildID:
+		return utils.SoundScopeGlobal
+	case userID == utils.LibraryUserID:
+		return utils.SoundScopeLibrary
+	default:
+		return utils.SoundScopeUser
+	}
+}
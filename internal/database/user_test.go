@@ -0,0 +1,39 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestGetSoundsByUserWithoutRecord(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	const guildID, userID = 1, 10
+
+	sounds, err := db.GetSoundsByUser(guildID, utils.LibraryUserID)
+	require.NoError(t, err)
+	assert.Empty(t, sounds)
+
+	// users only seen globally still get their global sounds
+	global := databasetest.CreateSound(t, db, utils.GlobalGuildID, userID, "global.mp3")
+	assert.Equal(t, []string{}, global.Tags)
+
+	sounds, err = db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	require.Len(t, sounds, 1)
+	assert.Equal(t, global.ID, sounds[0].ID)
+	assert.Equal(t, []string{}, sounds[0].Tags, "sounds without tags list none rather than null")
+
+	// the lookups are read-only
+	var users int64
+	require.NoError(t, db.Model(&models.User{}).Where("guild_id = ?", guildID).Count(&users).Error)
+	assert.Zero(t, users)
+}
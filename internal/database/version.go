@@ -0,0 +1,209 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ReplaceSoundFile replaces the audio of a sound with the given file while keeping its ID, archiving the previous audio
+// as a version in the sound's history. The sound is put back into the given review status. The quota is checked as in
+// CreateSound, for the new size in place of the old.
+func (db *DB) ReplaceSoundFile(id string, file *models.SoundFile, status string, quota *models.StorageQuota) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&sound).Error; err != nil {
+			return err
+		}
+
+		if err := checkStorageQuota(tx, sound.UserGuildID, sound.ID, file.Size, quota); err != nil {
+			return err
+		}
+
+		if err := archiveCurrentVersion(tx, &sound); err != nil {
+			return err
+		}
+
+		sound.OriginalName = file.OriginalName
+		sound.InternalFilename = file.InternalFilename
+		sound.MimeType = file.MimeType
+		sound.Size = file.Size
+		sound.Checksum = file.Checksum
+
+		return saveNewVersion(tx, &sound, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
+
+// RollbackSound makes a previous version of a sound's audio current again. The current audio is archived as a new
+// version, so a rollback can be undone in the same way. The quota is checked as in ReplaceSoundFile, for the size of
+// the previous version.
+func (db *DB) RollbackSound(id string, version int, status string, quota *models.StorageQuota) (*models.Sound, error) {
+	var sound models.Sound
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&sound).Error; err != nil {
+			return err
+		}
+
+		var previous models.SoundVersion
+		if err := tx.Where("sound_id = ? AND version = ?", id, version).First(&previous).Error; err != nil {
+			return err
+		}
+
+		if err := checkStorageQuota(tx, sound.UserGuildID, sound.ID, previous.Size, quota); err != nil {
+			return err
+		}
+
+		if err := archiveCurrentVersion(tx, &sound); err != nil {
+			return err
+		}
+
+		// the file now belongs to the sound again, so the version must not be purged along with it
+		if err := tx.Delete(&previous).Error; err != nil {
+			return fmt.Errorf("failed to delete sound version: %w", err)
+		}
+
+		sound.OriginalName = previous.OriginalName
+		sound.InternalFilename = previous.InternalFilename
+		sound.MimeType = previous.MimeType
+		sound.Size = previous.Size
+		sound.Checksum = previous.Checksum
+
+		return saveNewVersion(tx, &sound, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &sound, nil
+}
+
+// GetSoundVersions retrieves the previous versions of a sound, newest first.
+func (db *DB) GetSoundVersions(soundID string) ([]*models.SoundVersion, error) {
+	var versions []*models.SoundVersion
+
+	err := db.Where("sound_id = ?", soundID).Order("version DESC").Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// GetSoundVersion retrieves a previous version of a sound by its number.
+func (db *DB) GetSoundVersion(soundID string, version int) (*models.SoundVersion, error) {
+	var soundVersion models.SoundVersion
+
+	err := db.Where("sound_id = ? AND version = ?", soundID, version).First(&soundVersion).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &soundVersion, nil
+}
+
+// GetExpiredSoundVersions retrieves the sound versions that were replaced before the given time.
+func (db *DB) GetExpiredSoundVersions(before time.Time) ([]*models.SoundVersion, error) {
+	var versions []*models.SoundVersion
+
+	err := db.Where("replaced_at < ?", before.UTC()).Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// DeleteSoundVersion deletes a sound version record and schedules the removal of its file, which the caller should
+// then remove.
+func (db *DB) DeleteSoundVersion(id string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var version models.SoundVersion
+
+		err := tx.Where("id = ?", id).First(&version).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to delete sound version: %w", err)
+		}
+
+		if err := scheduleFileRemovals(tx, []string{version.InternalFilename}, time.Now()); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&version).Error; err != nil {
+			return fmt.Errorf("failed to delete sound version: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// archiveCurrentVersion stores the current audio of the sound in its history.
+func archiveCurrentVersion(tx *gorm.DB, sound *models.Sound) error {
+	id, err := gonanoid.New()
+	if err != nil {
+		return fmt.Errorf("failed to generate ID: %w", err)
+	}
+
+	uploadedAt := sound.CreatedAt
+	if sound.ReplacedAt != nil {
+		uploadedAt = *sound.ReplacedAt
+	}
+
+	version := models.SoundVersion{
+		ID:               id,
+		SoundID:          sound.ID,
+		Version:          sound.Version,
+		OriginalName:     sound.OriginalName,
+		InternalFilename: sound.InternalFilename,
+		MimeType:         sound.MimeType,
+		Size:             sound.Size,
+		Checksum:         sound.Checksum,
+		CreatedAt:        uploadedAt,
+		ReplacedAt:       time.Now().UTC(),
+	}
+
+	if err := tx.Create(&version).Error; err != nil {
+		return fmt.Errorf("failed to archive sound version: %w", err)
+	}
+
+	return nil
+}
+
+// saveNewVersion bumps the version of a sound whose audio was just swapped, and puts it into the given review status.
+// The new audio has not been scrubbed yet.
+func saveNewVersion(tx *gorm.DB, sound *models.Sound, status string) error {
+	now := time.Now().UTC()
+
+	sound.Version++
+	sound.ReplacedAt = &now
+	sound.Status = status
+	sound.Corrupt = false
+	sound.ScrubbedAt = nil
+
+	if status == utils.SoundStatusPending {
+		// the new audio has not been reviewed yet
+		sound.ReviewReason = nil
+		sound.ReviewedBy = nil
+		sound.ReviewedAt = nil
+	}
+
+	if err := tx.Save(sound).Error; err != nil {
+		return fmt.Errorf("failed to update sound: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestSoundVersions(t *testing.T) {
+	t.Parallel()
+
+	db := databasetest.New(t)
+
+	sound := databasetest.CreateSound(t, db, 1, 10, "v1.mp3")
+
+	replaced, err := db.ReplaceSoundFile(sound.ID, &models.SoundFile{OriginalName: "v2.wav", InternalFilename: "v2.wav", MimeType: "audio/x-wav"}, utils.SoundStatusPending, nil)
+	require.NoError(t, err)
+	assert.Equal(t, sound.ID, replaced.ID)
+	assert.Equal(t, 2, replaced.Version)
+	assert.Equal(t, "v2.wav", replaced.InternalFilename)
+	assert.Equal(t, utils.SoundStatusPending, replaced.Status)
+
+	versions, err := db.GetSoundVersions(sound.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 1, versions[0].Version)
+	assert.Equal(t, "v1.mp3", versions[0].InternalFilename)
+
+	rolledBack, err := db.RollbackSound(sound.ID, 1, utils.SoundStatusApproved, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, rolledBack.Version)
+	assert.Equal(t, "v1.mp3", rolledBack.InternalFilename)
+	assert.Equal(t, "audio/mpeg", rolledBack.MimeType)
+
+	// the rolled back version is current again, and the replaced audio is now in the history
+	versions, err = db.GetSoundVersions(sound.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, 2, versions[0].Version)
+	assert.Equal(t, "v2.wav", versions[0].InternalFilename)
+
+	expired, err := db.GetExpiredSoundVersions(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Len(t, expired, 1)
+
+	expired, err = db.GetExpiredSoundVersions(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// DeleteMyData permanently deletes everything held about the authenticated user across all guilds.
+func (h *Handler) DeleteMyData(c *gin.Context) {
+	h.deleteUserData(c, c.GetInt64("userID"), utils.DeletionRequestedBySelf)
+}
+
+// DeleteUserData permanently deletes everything held about a given user across all guilds, on behalf of an admin.
+func (h *Handler) DeleteUserData(c *gin.Context) {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.deleteUserData(c, userID, c.GetString("subject"))
+}
+
+// deleteUserData deletes the user's rows, scheduling the removal of their files in the same transaction, and then
+// removes the files. A file that fails to be removed is retried in the background rather than failing the request.
+func (h *Handler) deleteUserData(c *gin.Context, userID int64, requestedBy string) {
+	// the placeholder user owns every guild's library
+	if userID == utils.LibraryUserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	filenames, err := h.db.GetUserFilenames(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user files"})
+		return
+	}
+
+	deletion, err := h.db.DeleteUserData(userID, requestedBy)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No data found for user"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user data"})
+		return
+	}
+
+	// the removals are scheduled, so files that fail to be removed now are retried later
+	if err := h.db.RemoveFiles(c.Request.Context(), h.store, filenames); err != nil {
+		log.Printf("Failed to remove the files of deleted user %d: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "User data deleted",
+		"deletion": deletion,
+	})
+}
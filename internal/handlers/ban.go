@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetGuildBans returns the active bans in a given guild.
+func (h *Handler) GetGuildBans(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bans, err := h.db.GetGuildBans(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bans"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bans": bans,
+	})
+}
+
+// BanUser bans a given user from join sounds in a given guild.
+func (h *Handler) BanUser(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.BanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ban expiry must be in the future"})
+		return
+	}
+
+	var bannedBy *int64
+	if adminID := c.GetInt64("userID"); adminID != 0 {
+		bannedBy = &adminID
+	}
+
+	ban, err := h.db.BanUser(guildID, userID, &req, bannedBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to ban user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ban": ban,
+	})
+}
+
+// UnbanUser lifts a given user's ban in a given guild.
+func (h *Handler) UnbanUser(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.db.UnbanUser(guildID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ban not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unban user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unbanned successfully",
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// BatchUpdateSounds deletes and reorders several of a user's sounds in a guild in a single transaction. Ownership of
+// every sound is checked up front, and operations on sounds the user doesn't own fail without affecting the rest.
+func (h *Handler) BatchUpdateSounds(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.BatchSoundsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Delete) == 0 && len(req.Order) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No operations requested"})
+		return
+	}
+
+	if len(req.Delete)+len(req.Order) > utils.MaxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Can't perform more than %d operations at once", utils.MaxBatchSize)})
+		return
+	}
+
+	user, err := h.db.CreateOrGetUser(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	owned, err := h.db.GetSoundsByIDs(user.ID, append(append([]string{}, req.Delete...), req.Order...))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify sound ownership"})
+		return
+	}
+
+	ownedIDs := make(map[string]bool, len(owned))
+	for _, sound := range owned {
+		ownedIDs[sound.ID] = true
+	}
+
+	var deleteIDs, order []string
+
+	successfulItems := make([]*models.BatchItemResult, 0)
+	failedItems := make([]*models.BatchItemResult, 0)
+	deleting := make(map[string]bool, len(req.Delete))
+
+	for i, id := range req.Delete {
+		item := &models.BatchItemResult{SoundID: id, Operation: "delete", Index: i}
+
+		switch {
+		case !ownedIDs[id]:
+			item.Error = "sound not found"
+		case deleting[id]:
+			item.Error = "duplicate sound ID"
+		default:
+			deleting[id] = true
+			deleteIDs = append(deleteIDs, id)
+		}
+
+		if item.Error != "" {
+			failedItems = append(failedItems, item)
+		} else {
+			successfulItems = append(successfulItems, item)
+		}
+	}
+
+	ordered := make(map[string]bool, len(req.Order))
+
+	for i, id := range req.Order {
+		item := &models.BatchItemResult{SoundID: id, Operation: "order", Index: i}
+
+		switch {
+		case !ownedIDs[id]:
+			item.Error = "sound not found"
+		case deleting[id]:
+			item.Error = "sound is being deleted"
+		case ordered[id]:
+			item.Error = "duplicate sound ID"
+		default:
+			ordered[id] = true
+			order = append(order, id)
+		}
+
+		if item.Error != "" {
+			failedItems = append(failedItems, item)
+		} else {
+			successfulItems = append(successfulItems, item)
+		}
+	}
+
+	var newSound *models.Sound
+
+	if len(deleteIDs) > 0 || len(order) > 0 {
+		newSound, err = h.db.BatchUpdateSounds(user.ID, deleteIDs, order)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sounds"})
+			return
+		}
+	}
+
+	response := utils.BuildBatchResponse(successfulItems, failedItems)
+	if newSound != nil {
+		response.NewActiveSoundID = &newSound.ID
+	}
+
+	switch {
+	case response.FailureCount == 0:
+		c.JSON(http.StatusOK, response)
+	case response.SuccessCount > 0:
+		c.JSON(http.StatusMultiStatus, response)
+	default:
+		c.JSON(http.StatusBadRequest, response)
+	}
+}
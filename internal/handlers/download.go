@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/signing"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetSoundURL returns a URL to download a sound given its global ID, signed if sound URLs are signed. Sounds that can't
+// be played, such as those still awaiting review, are only available to their owner.
+func (h *Handler) GetSoundURL(c *gin.Context) {
+	sound, err := h.db.GetSoundByID(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	}
+
+	if !isPlayable(sound) {
+		owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+			return
+		}
+
+		if owner.UserID != c.GetInt64("userID") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sound is not available for playback"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, h.soundURL(sound.ID))
+}
+
+// verifySoundURL checks the signature of the requested sound URL, responding with an error and returning false if it
+// is not valid.
+func (h *Handler) verifySoundURL(c *gin.Context, soundID string) bool {
+	if h.signer == nil {
+		return true
+	}
+
+	err := h.signer.Verify(soundID, c.Request.URL.Query(), time.Now())
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, signing.ErrExpired):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sound URL has expired"})
+	case errors.Is(err, signing.ErrUnsigned):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Sound URL must be signed"})
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid sound URL signature"})
+	}
+
+	return false
+}
+
+// soundURL returns a URL to download the sound with the given ID.
+func (h *Handler) soundURL(soundID string) *models.SignedURL {
+	if h.signer == nil {
+		return &models.SignedURL{URL: signing.SoundPath(soundID)}
+	}
+
+	return h.signer.SignSound(soundID, time.Now())
+}
+
+// attachDownloads populates the download URL of the given sounds that can be played, or of all of them if
+// includeUnplayable is set, e.g. for their owner or for a guild admin reviewing them.
+func (h *Handler) attachDownloads(includeUnplayable bool, sounds ...*models.Sound) {
+	for _, sound := range sounds {
+		if sound != nil && (includeUnplayable || isPlayable(sound)) {
+			sound.Download = h.soundURL(sound.ID)
+		}
+	}
+}
+
+// isPlayable checks whether a sound may be played, and so downloaded by anyone who can hear it.
+func isPlayable(sound *models.Sound) bool {
+	return sound.Status == utils.SoundStatusApproved && !sound.Hidden && !sound.Broken && !sound.Corrupt
+}
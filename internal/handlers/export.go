@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ExportMyData streams a ZIP archive of everything held about the authenticated user: the file of each of their
+// sounds in every guild under its original name, and a manifest.json of their user, sound and setting records.
+func (h *Handler) ExportMyData(c *gin.Context) {
+	userID := c.GetInt64("userID")
+
+	manifest, err := h.db.GetUserExport(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user data"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="join-sounds-%d.zip"`, userID))
+	c.Status(http.StatusOK)
+
+	if err := h.writeExport(c.Request.Context(), zip.NewWriter(c.Writer), manifest); err != nil {
+		// the archive is already partly sent, so all we can do is cut it short
+		_ = c.Error(err)
+		c.Abort()
+	}
+}
+
+// writeExport writes the files of the exported sounds to the archive, followed by the manifest, which records where
+// each file ended up.
+func (h *Handler) writeExport(ctx context.Context, archive *zip.Writer, manifest *models.ExportManifest) error {
+	taken := make(map[string]bool, len(manifest.Sounds))
+
+	for _, exported := range manifest.Sounds {
+		folder := "global"
+		if exported.GuildID != utils.GlobalGuildID {
+			folder = strconv.FormatInt(exported.GuildID, 10)
+		}
+
+		name := path.Join("sounds", folder, path.Base(filepath.ToSlash(exported.Sound.OriginalName)))
+
+		file, err := addFileToArchive(ctx, archive, h.store, exported.Sound.InternalFilename, name, taken)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		exported.File = file
+	}
+
+	writer, err := archive.Create("manifest.json")
+	if err != nil {
+		return fmt.Errorf("failed to add manifest: %w", err)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return archive.Close()
+}
+
+// addFileToArchive copies the file stored under internalFilename into the archive under name, or a free variant of it,
+// and returns the name used. Audio is already compressed, so it is stored as is.
+func addFileToArchive(ctx context.Context, archive *zip.Writer, store storage.Storage, internalFilename, name string, taken map[string]bool) (string, error) {
+	file, err := store.Get(ctx, internalFilename)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	name = utils.UniqueFilename(name, taken)
+
+	writer, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return "", fmt.Errorf("failed to add %s: %w", name, err)
+	}
+
+	if _, err := io.Copy(writer, file); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetGuildSettings returns the settings of a given guild.
+func (h *Handler) GetGuildSettings(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	setting, err := h.db.GetGuildSetting(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"setting": setting,
+	})
+}
+
+// UpdateGuildSettings updates the settings of a given guild.
+func (h *Handler) UpdateGuildSettings(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req models.UpdateGuildSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.IsEmpty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if req.DefaultMode != nil && !slices.Contains(utils.AllowedModes, *req.DefaultMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Default mode can only be one of: " + strings.Join(utils.AllowedModes, ", ")})
+		return
+	}
+
+	if err := utils.ValidatePolicyOverrides(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := utils.ValidateGuildSchedule(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.DefaultSoundMode != nil && !slices.Contains(utils.AllowedModes, *req.DefaultSoundMode) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Default sound mode can only be one of: " + strings.Join(utils.AllowedModes, ", ")})
+		return
+	}
+
+	if req.ReportThreshold != nil && (*req.ReportThreshold < 0 || *req.ReportThreshold > utils.MaxReportThreshold) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Report threshold must be between 0 and %d", utils.MaxReportThreshold)})
+		return
+	}
+
+	var defaultSoundIDs []string
+
+	if req.DefaultSoundID != nil && *req.DefaultSoundID != "" {
+		defaultSoundIDs = append(defaultSoundIDs, *req.DefaultSoundID)
+	}
+
+	if req.DefaultSoundPool != nil {
+		if len(*req.DefaultSoundPool) > utils.MaxDefaultPoolSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Default sound pool can't have more than %d sounds", utils.MaxDefaultPoolSize)})
+			return
+		}
+
+		defaultSoundIDs = append(defaultSoundIDs, *req.DefaultSoundPool...)
+	}
+
+	for _, soundID := range defaultSoundIDs {
+		sound, err := h.db.GetSoundInGuild(guildID, soundID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sound not found in this guild: " + soundID})
+			return
+		}
+
+		if sound.Status != utils.SoundStatusApproved {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved sounds can be used as a default: " + soundID})
+			return
+		}
+	}
+
+	setting, err := h.db.UpdateGuildSetting(guildID, &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update guild settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"setting": setting,
+	})
+}
+
+// GetGuildPolicy returns the upload policy in effect for a given guild.
+func (h *Handler) GetGuildPolicy(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy": policy,
+	})
+}
+
+// GetGuildAdmins returns the users marked as admins of a given guild.
+func (h *Handler) GetGuildAdmins(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admins, err := h.db.GetGuildAdmins(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guild admins"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"admins": admins,
+	})
+}
+
+// AddGuildAdmin marks a user as an admin of a given guild.
+func (h *Handler) AddGuildAdmin(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	admin, err := h.db.AddGuildAdmin(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add guild admin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"admin": admin,
+	})
+}
+
+// RemoveGuildAdmin revokes a user's admin status in a given guild.
+func (h *Handler) RemoveGuildAdmin(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.db.RemoveGuildAdmin(guildID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Guild admin not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove guild admin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Guild admin removed successfully",
+	})
+}
+
+// GetGuildUsers returns the sounds and settings of every user in a given guild.
+func (h *Handler) GetGuildUsers(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.db.GetGuildUserOverviews(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guild users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+	})
+}
+
+func (h *Handler) getGuildPolicy(guildID int64) (*models.Policy, error) {
+	setting, err := h.db.GetGuildSetting(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.ResolvePolicy(setting), nil
+}
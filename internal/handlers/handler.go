@@ -6,17 +6,23 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/signing"
+	"github.com/mocbotau/api-join-sound/internal/storage"
 )
 
 // Handler is the HTTP handler for the API.
 type Handler struct {
-	db             *database.DB
-	soundsFilePath string
+	db                     *database.DB
+	store                  storage.Storage
+	signer                 *signing.Signer
+	trashCountsTowardQuota bool
 }
 
-// NewHandler creates a new Handler instance.
-func NewHandler(db *database.DB, soundsFilePath string) *Handler {
-	return &Handler{db: db, soundsFilePath: soundsFilePath}
+// NewHandler creates a new Handler instance, with sound files kept in the given store. Sound URLs are signed with the
+// given signer, or left unsigned if it is nil. Sounds in the trash count towards the file limit if
+// trashCountsTowardQuota is set.
+func NewHandler(db *database.DB, store storage.Storage, signer *signing.Signer, trashCountsTowardQuota bool) *Handler {
+	return &Handler{db: db, store: store, signer: signer, trashCountsTowardQuota: trashCountsTowardQuota}
 }
 
 // Ping responds with a pong message.
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ImportUserSounds handles the import of a ZIP archive of sounds for a given user in a given guild.
+func (h *Handler) ImportUserSounds(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	status := utils.SoundStatusApproved
+	if policy.RequireApproval {
+		status = utils.SoundStatusPending
+	}
+
+	h.importSounds(c, guildID, userID, policy, policy.MaxFilesPerUser, status)
+}
+
+// ImportMySounds handles the import of a ZIP archive of global sounds for the authenticated user.
+func (h *Handler) ImportMySounds(c *gin.Context) {
+	policy := utils.DefaultPolicy()
+
+	h.importSounds(c, utils.GlobalGuildID, c.GetInt64("userID"), policy, policy.MaxFilesPerUser, utils.SoundStatusApproved)
+}
+
+// ImportLibrarySounds handles the import of a ZIP archive of sounds to a given guild's library.
+func (h *Handler) ImportLibrarySounds(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	// library sounds are curated by admins, so they never need approval
+	h.importSounds(c, guildID, utils.LibraryUserID, policy, utils.MaxLibrarySounds, utils.SoundStatusApproved)
+}
+
+// importSounds stores the sounds in the uploaded archive for the given user, up to maxSounds sounds in total. Each
+// file in the archive is validated against the policy as if it had been uploaded on its own, and gets the metadata
+// given for it in the archive's manifest, if any.
+func (h *Handler) importSounds(c *gin.Context, guildID, userID int64, policy *models.Policy, maxSounds int, status string) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No archive uploaded"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open uploaded archive"})
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	archive, err := zip.NewReader(file, fileHeader.Size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ZIP archive"})
+		return
+	}
+
+	if len(archive.File) > utils.MaxImportEntries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Archive can't contain more than %d entries", utils.MaxImportEntries)})
+		return
+	}
+
+	metadata, err := utils.ReadImportManifest(archive)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries := make([]*zip.File, 0, len(archive.File))
+
+	for _, entry := range archive.File {
+		// skip directories, the manifest, and the resource forks added by macOS
+		if entry.FileInfo().IsDir() || entry.Name == utils.ImportManifestName || strings.HasPrefix(entry.Name, "__MACOSX/") {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files found in archive"})
+		return
+	}
+
+	fileUploader := h.newFileUploader(c, guildID, userID, policy, maxSounds, status)
+	if fileUploader == nil {
+		return
+	}
+
+	respondBulkUpload(c, fileUploader.processImport(c.Request.Context(), entries, metadata))
+}
+
+func (fu *fileUploader) processImport(ctx context.Context, entries []*zip.File, metadata map[string]*models.UpdateSoundRequest) models.BulkUploadResponse {
+	for i, entry := range entries {
+		if uploadResponse, err := fu.importSingleFile(ctx, entry, metadata[path.Clean(entry.Name)]); err != nil {
+			fu.failedFiles = append(fu.failedFiles, &models.FileError{
+				Filename: entry.Name,
+				Error:    err.Error(),
+				Index:    i,
+			})
+		} else {
+			fu.successFiles = append(fu.successFiles, &uploadResponse)
+			fu.currentSoundCount++
+		}
+	}
+
+	return utils.BuildBulkUploadResponse(len(entries), fu.successFiles, fu.failedFiles)
+}
+
+func (fu *fileUploader) importSingleFile(ctx context.Context, entry *zip.File, metadata *models.UpdateSoundRequest) (models.UploadResponse, error) {
+	if fu.currentSoundCount >= fu.maxSounds {
+		return models.UploadResponse{}, fmt.Errorf("maximum file limit of %d reached", fu.maxSounds)
+	}
+
+	// entries are never extracted under their own name, but one that points outside the archive is not to be trusted
+	if !filepath.IsLocal(filepath.FromSlash(entry.Name)) {
+		return models.UploadResponse{}, fmt.Errorf("invalid path in archive: %s", entry.Name)
+	}
+
+	if metadata == nil {
+		metadata = &models.UpdateSoundRequest{}
+	}
+
+	if err := utils.NormalizeSoundMetadata(metadata); err != nil {
+		return models.UploadResponse{}, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	fileID, err := gonanoid.New()
+	if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("failed to generate file ID: %w", err)
+	}
+
+	// extract to a temporary file, as the file must be validated before it is stored
+	temp, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer func() {
+		_ = temp.Close()
+		_ = os.Remove(temp.Name())
+	}()
+
+	size, err := utils.ExtractArchiveEntry(entry, temp, fu.policy.MaxUploadSize)
+	if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("file validation failed: %w", err)
+	}
+
+	originalName := path.Base(entry.Name)
+
+	mimeType, err := utils.ValidateStoredFile(temp, size, originalName, fu.policy)
+	if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("file validation failed: %w", err)
+	}
+
+	if _, err := temp.Seek(0, io.SeekStart); err != nil {
+		return models.UploadResponse{}, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	internalFilename := utils.GenerateInternalFilename(fileID, mimeType)
+
+	reader := utils.NewChecksumReader(temp)
+
+	err = storeNewFile(fu.db, internalFilename, func() error {
+		return fu.store.Put(ctx, internalFilename, reader, size)
+	})
+	if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	soundFile := &models.SoundFile{
+		OriginalName:     originalName,
+		InternalFilename: internalFilename,
+		MimeType:         mimeType,
+		Size:             size,
+		Checksum:         reader.Checksum(),
+	}
+
+	sound, err := fu.db.CreateSoundWithMetadata(fu.user.ID, soundFile, fu.status, metadata, fu.quota)
+	settleNewFile(ctx, fu.db, fu.store, internalFilename, err == nil)
+
+	if quotaErr := storageQuotaError(err, fu.quota); quotaErr != nil {
+		return models.UploadResponse{}, quotaErr
+	} else if err != nil {
+		return models.UploadResponse{}, fmt.Errorf("failed to store file record: %w", err)
+	}
+
+	return models.UploadResponse{
+		ID:           sound.ID,
+		OriginalName: originalName,
+		Size:         size,
+		MimeType:     mimeType,
+		Status:       fu.status,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetGuildLibrary returns the sounds in a given guild's library.
+func (h *Handler) GetGuildLibrary(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sounds, err := h.db.GetSoundsByUser(guildID, utils.LibraryUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch library sounds"})
+		return
+	}
+
+	if c.GetBool("guildMember") {
+		h.attachDownloads(false, sounds...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds": sounds,
+	})
+}
+
+// UploadLibrarySounds handles the upload of sounds to a given guild's library.
+func (h *Handler) UploadLibrarySounds(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	// library sounds are curated by admins, so they never need approval
+	h.uploadSounds(c, guildID, utils.LibraryUserID, policy, utils.MaxLibrarySounds, utils.SoundStatusApproved)
+}
+
+// DeleteLibrarySound deletes a sound from a given guild's library.
+func (h *Handler) DeleteLibrarySound(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundInGuild(guildID, c.Param("soundId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound"})
+		return
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	if owner.UserID != utils.LibraryUserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound is not in the guild library"})
+		return
+	}
+
+	h.deleteSound(c, sound.ID)
+}
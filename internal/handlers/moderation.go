@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetPendingSounds returns the sounds awaiting review in a given guild.
+func (h *Handler) GetPendingSounds(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items, err := h.db.GetModerationQueue(guildID, utils.SoundStatusPending)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pending sounds"})
+		return
+	}
+
+	for _, item := range items {
+		h.attachDownloads(true, item.Sound)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds": items,
+	})
+}
+
+// ApproveSound approves a pending sound, allowing it to be played.
+func (h *Handler) ApproveSound(c *gin.Context) {
+	h.reviewSound(c, utils.SoundStatusApproved, nil)
+}
+
+// RejectSound rejects a pending sound, with a reason that is shown to the uploader.
+func (h *Handler) RejectSound(c *gin.Context) {
+	var req models.ReviewSoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.reviewSound(c, utils.SoundStatusRejected, &req.Reason)
+}
+
+func (h *Handler) reviewSound(c *gin.Context, status string, reason *string) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundInGuild(guildID, c.Param("soundId"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound"})
+		return
+	}
+
+	if sound.Status != utils.SoundStatusPending {
+		c.JSON(http.StatusConflict, gin.H{"error": "Sound is not awaiting review"})
+		return
+	}
+
+	var reviewerID *int64
+	if userID := c.GetInt64("userID"); userID != 0 {
+		reviewerID = &userID
+	}
+
+	sound, err = h.db.ReviewSound(sound.ID, status, reason, reviewerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review sound"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sound": sound,
+	})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// CreatePlay records that the bot played a sound.
+func (h *Handler) CreatePlay(c *gin.Context) {
+	var req models.CreatePlayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundByID(req.SoundID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sound not found"})
+		return
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	// the sound must be one of the guild's own, its library included, or one of the user's global sounds
+	if owner.GuildID != req.GuildID && (owner.GuildID != utils.GlobalGuildID || owner.UserID != req.UserID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sound can't be played in this guild"})
+		return
+	}
+
+	play, err := h.db.CreatePlay(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record play"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"play": play,
+	})
+}
+
+// GetUserPlays returns the play history of a given user in a given guild.
+func (h *Handler) GetUserPlays(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := utils.GetPlayFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plays, total, err := h.db.GetPlaysByUser(guildID, userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch plays"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plays":  plays,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetGuildLeaderboard returns the most played sounds and users in a given guild.
+func (h *Handler) GetGuildLeaderboard(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter, err := utils.GetPlayFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	topSounds, err := h.db.GetTopSounds(guildID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top sounds"})
+		return
+	}
+
+	topUsers, err := h.db.GetTopUsers(guildID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch top users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds": topSounds,
+		"users":  topUsers,
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ResolveUserSound determines which sound the bot should play when a given user joins a channel in a given guild.
+func (h *Handler) ResolveUserSound(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildSetting, err := h.db.GetGuildSetting(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild settings"})
+		return
+	}
+
+	if !guildSetting.Enabled {
+		c.JSON(http.StatusOK, models.Resolution{Play: false, Reason: utils.ResolutionReasonGuildDisabled})
+		return
+	}
+
+	if utils.InQuietHours(guildSetting, time.Now()) {
+		c.JSON(http.StatusOK, models.Resolution{Play: false, Reason: utils.ResolutionReasonQuietHours})
+		return
+	}
+
+	ban, err := h.db.GetActiveBan(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ban status"})
+		return
+	}
+
+	if ban != nil {
+		c.JSON(http.StatusOK, models.Resolution{Play: false, Reason: utils.ResolutionReasonBanned})
+		return
+	}
+
+	sound, source, err := h.db.ResolveSound(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve sound"})
+		return
+	}
+
+	if sound == nil {
+		c.JSON(http.StatusOK, models.Resolution{Play: false, Reason: utils.ResolutionReasonNoSound})
+		return
+	}
+
+	h.attachDownloads(false, sound)
+
+	c.JSON(http.StatusOK, models.Resolution{Play: true, Source: source, Sound: sound})
+}
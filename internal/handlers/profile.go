@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetMySounds retrieves the authenticated user's global sounds, which can be used in every guild, along with the
+// storage they take up against the user's quota.
+func (h *Handler) GetMySounds(c *gin.Context) {
+	userID := c.GetInt64("userID")
+
+	sounds, err := h.db.GetSoundsByUser(utils.GlobalGuildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sounds"})
+		return
+	}
+
+	h.attachDownloads(true, sounds...)
+
+	usage, err := h.getStorageUsage(utils.GlobalGuildID, userID, utils.DefaultPolicy())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds":  sounds,
+		"storage": usage,
+	})
+}
+
+// UploadMySounds handles the upload of global sounds for the authenticated user. Global sounds are checked against
+// the default policy, and can't be used in guilds that require approval.
+func (h *Handler) UploadMySounds(c *gin.Context) {
+	policy := utils.DefaultPolicy()
+
+	h.uploadSounds(c, utils.GlobalGuildID, c.GetInt64("userID"), policy, policy.MaxFilesPerUser, utils.SoundStatusApproved)
+}
+
+// GetMySettings returns the authenticated user's global settings, which apply in every guild where they haven't set
+// any of their own.
+func (h *Handler) GetMySettings(c *gin.Context) {
+	setting, settingSource, err := h.db.GetUserSetting(utils.GlobalGuildID, c.GetInt64("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"setting":        setting,
+		"setting_source": settingSource,
+	})
+}
+
+// UpdateMySettings updates the authenticated user's global settings.
+func (h *Handler) UpdateMySettings(c *gin.Context) {
+	h.updateSettings(c, utils.GlobalGuildID, c.GetInt64("userID"))
+}
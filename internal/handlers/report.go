@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ReportSound records a guild member's report of an offensive sound.
+func (h *Handler) ReportSound(c *gin.Context) {
+	var req models.ReportSoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundByID(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	}
+
+	guildID := c.GetInt64("guildID")
+	reporterID := c.GetInt64("userID")
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	if owner.UserID == reporterID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't report your own sound"})
+		return
+	}
+
+	guildSetting, err := h.db.GetGuildSetting(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild settings"})
+		return
+	}
+
+	report, err := h.db.CreateReport(sound, guildID, reporterID, req.Reason, guildSetting.ReportThreshold)
+	if errors.Is(err, database.ErrAlreadyReported) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this sound"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to report sound"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"report": report,
+	})
+}
+
+// GetGuildReports returns the reports in a given guild, open ones unless another status is requested.
+func (h *Handler) GetGuildReports(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.DefaultQuery("status", utils.ReportStatusOpen)
+	if !slices.Contains([]string{utils.ReportStatusOpen, utils.ReportStatusResolved, utils.ReportStatusDismissed}, status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report status"})
+		return
+	}
+
+	reports, err := h.db.GetGuildReports(guildID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+	})
+}
+
+// ResolveReport upholds the reports of a sound, keeping it hidden from playback.
+func (h *Handler) ResolveReport(c *gin.Context) {
+	h.decideReport(c, utils.ReportStatusResolved)
+}
+
+// DismissReport dismisses the reports of a sound, making it playable again.
+func (h *Handler) DismissReport(c *gin.Context) {
+	h.decideReport(c, utils.ReportStatusDismissed)
+}
+
+func (h *Handler) decideReport(c *gin.Context, status string) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var adminID *int64
+	if userID := c.GetInt64("userID"); userID != 0 {
+		adminID = &userID
+	}
+
+	sound, err := h.db.DecideReport(guildID, c.Param("reportId"), status, adminID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sound": sound,
+	})
+}
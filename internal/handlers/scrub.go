@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/jobs"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetIntegrityReport returns the sounds whose file was found corrupt across all guilds, along with how much of the
+// stored files has yet to be checked.
+func (h *Handler) GetIntegrityReport(c *gin.Context) {
+	report, err := h.db.GetIntegrityReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch integrity report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ScrubUserSounds checks the files of a given user's sounds across all guilds against their checksums straight away,
+// rather than waiting for the background scrub to reach them.
+func (h *Handler) ScrubUserSounds(c *gin.Context) {
+	userID, err := utils.GetUserID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sounds, err := h.db.GetUserSoundsToScrub(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user sounds"})
+		return
+	}
+
+	if len(sounds) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No sounds found for user"})
+		return
+	}
+
+	report, err := jobs.Scrub(c.Request.Context(), h.db, h.store, sounds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check some sound files", "report": report})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"report": report,
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// SearchGuildSounds searches the sounds the caller can pick in a guild by name, tags and description.
+func (h *Handler) SearchGuildSounds(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	if utf8.RuneCountInString(query) > utils.MaxSearchQueryLen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Search query can be at most %d characters", utils.MaxSearchQueryLen)})
+		return
+	}
+
+	limit, offset, err := utils.GetPagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID := c.GetInt64("guildID")
+
+	guildSetting, err := h.db.GetGuildSetting(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild settings"})
+		return
+	}
+
+	// global sounds can't be picked in guilds that require approval
+	sounds, total, err := h.db.SearchSounds(guildID, c.GetInt64("userID"), query, !guildSetting.RequireApproval, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search sounds"})
+		return
+	}
+
+	h.attachDownloads(false, sounds...)
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds": sounds,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
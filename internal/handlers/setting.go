@@ -11,7 +11,8 @@ import (
 	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
-// GetUserSettings returns user settings with active sound details.
+// GetUserSettings returns user settings along with the sound that is effectively played for the user, and whether it
+// is their own or the guild default. In random mode the effective sound is a random pick, as the bot would make.
 func (h *Handler) GetUserSettings(c *gin.Context) {
 	guildID, userID, err := utils.GetUserGuildID(c)
 	if err != nil {
@@ -19,15 +20,23 @@ func (h *Handler) GetUserSettings(c *gin.Context) {
 		return
 	}
 
-	setting, err := h.db.GetOrCreateUserSetting(guildID, userID)
+	setting, settingSource, err := h.db.GetUserSetting(guildID, userID)
 	if err != nil {
-		// should always automatically create settings if they don't exist
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve settings"})
 		return
 	}
 
+	sound, source, err := h.db.ResolveSound(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve effective sound"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"setting": setting,
+		"setting":         setting,
+		"setting_source":  settingSource,
+		"effective_sound": sound,
+		"source":          source,
 	})
 }
 
@@ -39,6 +48,11 @@ func (h *Handler) UpdateUserSettings(c *gin.Context) {
 		return
 	}
 
+	h.updateSettings(c, guildID, userID)
+}
+
+// updateSettings updates the settings of the given user in the given guild, or their global settings.
+func (h *Handler) updateSettings(c *gin.Context, guildID, userID int64) {
 	var req models.UpdateSettingsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -66,8 +80,47 @@ func (h *Handler) UpdateUserSettings(c *gin.Context) {
 			return
 		}
 
-		if sound.UserGuildID != user.ID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Sound does not belong to this user"})
+		scope, err := h.db.GetSoundScope(user, sound)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify sound ownership"})
+			return
+		}
+
+		if scope == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Sound does not belong to this user or the guild library"})
+			return
+		}
+
+		if scope == utils.SoundScopeGlobal {
+			guildSetting, err := h.db.GetGuildSetting(guildID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild settings"})
+				return
+			}
+
+			if guildSetting.RequireApproval {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Global sounds can't be used in guilds that require approval"})
+				return
+			}
+		}
+
+		if sound.Status != utils.SoundStatusApproved {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved sounds can be set as active"})
+			return
+		}
+
+		if sound.Hidden {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sound has been hidden after being reported"})
+			return
+		}
+
+		if sound.Broken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sound file is missing"})
+			return
+		}
+
+		if sound.Corrupt {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sound file is corrupt"})
 			return
 		}
 	}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// CreateShareToken creates a share token that lets other users clone one of the caller's sounds.
+func (h *Handler) CreateShareToken(c *gin.Context) {
+	var req models.CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+
+	share, err := h.db.CreateShareToken(c.Param("soundId"), c.GetInt64("userID"), &req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"share": share,
+	})
+}
+
+// GetShareTokens returns the share tokens of one of the caller's sounds.
+func (h *Handler) GetShareTokens(c *gin.Context) {
+	shares, err := h.db.GetShareTokens(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch share tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shares": shares,
+	})
+}
+
+// RevokeShareToken revokes a share token of one of the caller's sounds.
+func (h *Handler) RevokeShareToken(c *gin.Context) {
+	err := h.db.RevokeShareToken(c.Param("soundId"), c.Param("token"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share token not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Share token revoked successfully",
+	})
+}
+
+// RedeemShareToken clones a shared sound into the caller's sounds in the requested guild, subject to the same checks
+// as copying a sound: the caller must be a member of the guild, and the sound approved and within the guild's policy.
+func (h *Handler) RedeemShareToken(c *gin.Context) {
+	var req models.RedeemShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token := c.Param("token")
+
+	share, err := h.db.ClaimShareToken(token)
+	if errors.Is(err, database.ErrShareTokenInvalid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link is invalid or has expired"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeem share link"})
+		return
+	}
+
+	sound, err := h.db.GetSoundByID(share.SoundID)
+	if err != nil {
+		_ = h.db.ReleaseShareToken(token)
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+
+		return
+	}
+
+	newSound := h.copySound(c, sound, req.GuildID, c.GetInt64("userID"))
+	if newSound == nil {
+		// the token can be used again once the caller has sorted out the problem, e.g. freed up space
+		_ = h.db.ReleaseShareToken(token)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sound": newSound,
+	})
+}
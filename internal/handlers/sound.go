@@ -1,18 +1,23 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"gorm.io/gorm"
 
 	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/middleware"
 	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
 	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
@@ -20,12 +25,16 @@ type fileUploader struct {
 	currentSoundCount int
 	db                *database.DB
 	failedFiles       []*models.FileError
-	soundsFilePath    string
+	maxSounds         int
+	policy            *models.Policy
+	quota             *models.StorageQuota
+	status            string
+	store             storage.Storage
 	successFiles      []*models.UploadResponse
 	user              *models.User
 }
 
-// GetSound retrieves a sound by its global ID.
+// GetSound retrieves a sound by its global ID. When sound URLs are signed, the request must carry a valid signature.
 func (h *Handler) GetSound(c *gin.Context) {
 	soundID := c.Param("soundId")
 	if soundID == "" {
@@ -33,19 +42,42 @@ func (h *Handler) GetSound(c *gin.Context) {
 		return
 	}
 
+	if !h.verifySoundURL(c, soundID) {
+		return
+	}
+
 	sound, err := h.db.GetSoundByID(soundID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
 		return
 	}
 
+	file, err := h.store.Get(c.Request.Context(), sound.InternalFilename)
+	if errors.Is(err, fs.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound file not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open sound file"})
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open sound file"})
+		return
+	}
+
 	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", sound.OriginalName))
 	c.Header("Content-Type", sound.MimeType)
 	c.Header("X-Content-Type-Options", "nosniff")
-	c.File(fmt.Sprintf("%s/%s", h.soundsFilePath, sound.InternalFilename))
+	http.ServeContent(c.Writer, c.Request, sound.InternalFilename, info.ModTime(), file)
 }
 
-// DeleteSound deletes a sound given its global ID.
+// DeleteSound moves a sound to the trash given its global ID.
 func (h *Handler) DeleteSound(c *gin.Context) {
 	soundID := c.Param("soundId")
 	if soundID == "" {
@@ -53,7 +85,12 @@ func (h *Handler) DeleteSound(c *gin.Context) {
 		return
 	}
 
-	deletedSound, newSound, err := h.db.DeleteSound(soundID)
+	h.deleteSound(c, soundID)
+}
+
+// deleteSound moves a sound to the trash. Its file is removed when the trash is purged.
+func (h *Handler) deleteSound(c *gin.Context, soundID string) {
+	deletedSound, newSound, err := h.db.TrashSound(soundID)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
 		return
@@ -62,20 +99,186 @@ func (h *Handler) DeleteSound(c *gin.Context) {
 		return
 	}
 
-	err = os.Remove(fmt.Sprintf("%s/%s", h.soundsFilePath, deletedSound.InternalFilename))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sound file"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Sound moved to trash",
+		"deleted_sound": deletedSound,
+		"new_sound":     newSound,
+	})
+}
+
+// UpdateSound updates the metadata of a sound given its global ID.
+func (h *Handler) UpdateSound(c *gin.Context) {
+	var req models.UpdateSoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.DisplayName == nil && req.Emoji == nil && req.Tags == nil && req.Description == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := utils.NormalizeSoundMetadata(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.UpdateSoundMetadata(c.Param("soundId"), &req)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sound"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":       "Sound deleted successfully",
-		"deleted_sound": deletedSound,
-		"new_sound":     newSound,
+		"sound": sound,
+	})
+}
+
+// CopySound copies one of the caller's sounds to their sounds in another guild, without re-uploading it. The copy is
+// subject to the target guild's policy.
+func (h *Handler) CopySound(c *gin.Context) {
+	var req models.CopySoundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundByID(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	if owner.GuildID == req.GuildID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sound is already in this guild"})
+		return
+	}
+
+	newSound := h.copySound(c, sound, req.GuildID, c.GetInt64("userID"))
+	if newSound == nil {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sound": newSound,
+	})
+}
+
+// copySound stores a copy of the sound for the given user in the given guild, checked against the user's membership
+// and ban status there and the guild's policy. It writes an error response and returns nil if the sound can't be
+// copied.
+func (h *Handler) copySound(c *gin.Context, sound *models.Sound, guildID, userID int64) *models.Sound {
+	// sounds that haven't passed review would otherwise skip it
+	if sound.Status != utils.SoundStatusApproved || sound.Hidden {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved sounds that aren't hidden can be copied"})
+		return nil
+	}
+
+	if sound.Corrupt {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sound file is corrupt"})
+		return nil
+	}
+
+	isMember, err := middleware.IsGuildMember(c, h.db, guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guild membership"})
+		return nil
+	}
+
+	if !isMember {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You must be a member of this guild"})
+		return nil
+	}
+
+	ban, err := h.db.GetActiveBan(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ban status"})
+		return nil
+	}
+
+	if ban != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from using join sounds in this guild"})
+		return nil
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return nil
+	}
+
+	currentSoundCount, err := h.db.CountSoundsByUser(guildID, userID, h.trashCountsTowardQuota)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch current sounds"})
+		return nil
+	}
+
+	if currentSoundCount >= int64(policy.MaxFilesPerUser) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("maximum file limit of %d reached", policy.MaxFilesPerUser)})
+		return nil
+	}
+
+	mimeType, size, err := validateStoredFile(c.Request.Context(), h.store, sound.InternalFilename, sound.OriginalName, policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file validation failed: %s", err)})
+		return nil
+	}
+
+	user, err := h.db.CreateOrGetUser(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return nil
+	}
+
+	fileID, err := gonanoid.New()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate file ID"})
+		return nil
+	}
+
+	internalFilename := utils.GenerateInternalFilename(fileID, mimeType)
+
+	err = storeNewFile(h.db, internalFilename, func() error {
+		return h.store.Copy(c.Request.Context(), sound.InternalFilename, internalFilename)
 	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy sound file"})
+		return nil
+	}
+
+	status := utils.SoundStatusApproved
+	if policy.RequireApproval {
+		status = utils.SoundStatusPending
+	}
+
+	quota := h.storageQuota(guildID, userID, policy)
+
+	newSound, err := h.db.CloneSound(sound, user.ID, internalFilename, status, size, quota)
+	settleNewFile(c.Request.Context(), h.db, h.store, internalFilename, err == nil)
+
+	if quotaErr := storageQuotaError(err, quota); quotaErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": quotaErr.Error()})
+		return nil
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store sound record"})
+		return nil
+	}
+
+	return newSound
 }
 
-// GetUserSounds retrieves all sounds for a given user in a given guild.
+// GetUserSounds retrieves all sounds for a given user in a given guild, including their global sounds, along with the
+// storage their sounds in the guild take up against their quota.
 func (h *Handler) GetUserSounds(c *gin.Context) {
 	guildID, userID, err := utils.GetUserGuildID(c)
 	if err != nil {
@@ -89,8 +292,26 @@ func (h *Handler) GetUserSounds(c *gin.Context) {
 		return
 	}
 
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	usage, err := h.getStorageUsage(guildID, userID, policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+
+	// members of the guild can play the user's sounds, and the user can also preview those awaiting review
+	if c.GetBool("guildMember") {
+		h.attachDownloads(c.GetInt64("userID") == userID, sounds...)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"sounds": sounds,
+		"sounds":  sounds,
+		"storage": usage,
 	})
 }
 
@@ -102,6 +323,23 @@ func (h *Handler) UploadUserSounds(c *gin.Context) {
 		return
 	}
 
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	status := utils.SoundStatusApproved
+	if policy.RequireApproval {
+		status = utils.SoundStatusPending
+	}
+
+	h.uploadSounds(c, guildID, userID, policy, policy.MaxFilesPerUser, status)
+}
+
+// uploadSounds validates the uploaded files against the policy and stores them for the given user, up to maxSounds
+// sounds in total.
+func (h *Handler) uploadSounds(c *gin.Context, guildID, userID int64, policy *models.Policy, maxSounds int, status string) {
 	form, err := c.MultipartForm()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -114,34 +352,52 @@ func (h *Handler) UploadUserSounds(c *gin.Context) {
 		return
 	}
 
-	if len(files) > utils.MaxFilesPerUser {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Can't upload more than %d files at once", utils.MaxFilesPerUser)})
+	if len(files) > maxSounds {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Can't upload more than %d files at once", maxSounds)})
+		return
+	}
+
+	fileUploader := h.newFileUploader(c, guildID, userID, policy, maxSounds, status)
+	if fileUploader == nil {
 		return
 	}
 
+	respondBulkUpload(c, fileUploader.processBulkUpload(c, files))
+}
+
+// newFileUploader prepares the upload of sounds for the given user, up to maxSounds sounds in total. If it fails, it
+// responds with the error and returns nil.
+func (h *Handler) newFileUploader(c *gin.Context, guildID, userID int64, policy *models.Policy, maxSounds int, status string) *fileUploader {
 	user, err := h.db.CreateOrGetUser(guildID, userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
-		return
+		return nil
 	}
 
-	currentSounds, err := h.db.GetSoundsByUser(guildID, userID)
+	// global sounds don't count towards the guild's limit
+	currentSoundCount, err := h.db.CountSoundsByUser(guildID, userID, h.trashCountsTowardQuota)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch current sounds"})
-		return
+		return nil
 	}
 
-	fileUploader := &fileUploader{
-		currentSoundCount: len(currentSounds),
+	return &fileUploader{
+		currentSoundCount: int(currentSoundCount),
 		db:                h.db,
 		failedFiles:       make([]*models.FileError, 0),
-		soundsFilePath:    h.soundsFilePath,
+		maxSounds:         maxSounds,
+		policy:            policy,
+		quota:             h.storageQuota(guildID, userID, policy),
+		status:            status,
+		store:             h.store,
 		successFiles:      make([]*models.UploadResponse, 0),
 		user:              user,
 	}
+}
 
-	response := fileUploader.processBulkUpload(c, files)
-
+// respondBulkUpload responds with the outcome of a bulk upload, using a status code that reflects whether some or all
+// of the files were uploaded.
+func respondBulkUpload(c *gin.Context, response models.BulkUploadResponse) {
 	if response.SuccessCount > 0 {
 		if response.FailureCount > 0 {
 			c.JSON(http.StatusMultiStatus, response)
@@ -171,11 +427,11 @@ func (fu *fileUploader) processBulkUpload(c *gin.Context, files []*multipart.Fil
 }
 
 func (fu *fileUploader) uploadSingleFile(c *gin.Context, file *multipart.FileHeader) (models.UploadResponse, error) {
-	if fu.currentSoundCount >= utils.MaxFilesPerUser {
-		return models.UploadResponse{}, fmt.Errorf("maximum file limit of %d reached per user", utils.MaxFilesPerUser)
+	if fu.currentSoundCount >= fu.maxSounds {
+		return models.UploadResponse{}, fmt.Errorf("maximum file limit of %d reached", fu.maxSounds)
 	}
 
-	mimeType, err := utils.ValidateFileUpload(file)
+	mimeType, err := utils.ValidateFileUpload(file, fu.policy)
 	if err != nil {
 		return models.UploadResponse{}, fmt.Errorf("file validation failed: %w", err)
 	}
@@ -187,18 +443,30 @@ func (fu *fileUploader) uploadSingleFile(c *gin.Context, file *multipart.FileHea
 
 	internalFilename := utils.GenerateInternalFilename(fileID, mimeType)
 
-	filePath := fmt.Sprintf("%s/%s", fu.soundsFilePath, internalFilename)
-	if err := c.SaveUploadedFile(file, filePath); err != nil {
+	var checksum string
+
+	err = storeNewFile(fu.db, internalFilename, func() (err error) {
+		checksum, err = saveUploadedFile(c.Request.Context(), fu.store, file, internalFilename)
+		return err
+	})
+	if err != nil {
 		return models.UploadResponse{}, fmt.Errorf("failed to save file: %w", err)
 	}
 
-	sound, err := fu.db.CreateSound(fu.user.ID, file.Filename, internalFilename, mimeType)
-	if err != nil {
-		removeErr := os.Remove(filePath)
-		if removeErr != nil {
-			return models.UploadResponse{}, fmt.Errorf("failed to remove uploaded file: %w", removeErr)
-		}
+	soundFile := &models.SoundFile{
+		OriginalName:     file.Filename,
+		InternalFilename: internalFilename,
+		MimeType:         mimeType,
+		Size:             file.Size,
+		Checksum:         checksum,
+	}
+
+	sound, err := fu.db.CreateSound(fu.user.ID, soundFile, fu.status, fu.quota)
+	settleNewFile(c.Request.Context(), fu.db, fu.store, internalFilename, err == nil)
 
+	if quotaErr := storageQuotaError(err, fu.quota); quotaErr != nil {
+		return models.UploadResponse{}, quotaErr
+	} else if err != nil {
 		return models.UploadResponse{}, fmt.Errorf("failed to store file record: %w", err)
 	}
 
@@ -207,5 +475,74 @@ func (fu *fileUploader) uploadSingleFile(c *gin.Context, file *multipart.FileHea
 		OriginalName: file.Filename,
 		Size:         file.Size,
 		MimeType:     mimeType,
+		Status:       fu.status,
 	}, nil
 }
+
+// storeNewFile stores a new file through put. The file's removal is scheduled first, so that it is removed if the
+// process dies before the record of its sound is written. settleNewFile must be called once the record is written, or
+// has failed to be.
+func storeNewFile(db *database.DB, internalFilename string, put func() error) error {
+	if err := db.ScheduleFileRemoval(internalFilename, time.Now().Add(utils.UploadGracePeriod)); err != nil {
+		return err
+	}
+
+	return put()
+}
+
+// settleNewFile keeps a new file if the record referring to it was written, and removes it otherwise. Anything that
+// fails here is left to the scheduled removal, which never removes a file that is referred to.
+func settleNewFile(ctx context.Context, db *database.DB, store storage.Storage, internalFilename string, written bool) {
+	if written {
+		_ = db.DeleteFileRemoval(internalFilename)
+		return
+	}
+
+	if err := db.RemoveFiles(ctx, store, []string{internalFilename}); err != nil {
+		log.Printf("Failed to remove unused file %s: %v", internalFilename, err)
+	}
+}
+
+// saveUploadedFile stores an uploaded file under the given internal filename, returning its checksum.
+func saveUploadedFile(ctx context.Context, store storage.Storage, fileHeader *multipart.FileHeader, internalFilename string) (string, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("cannot open uploaded file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := utils.NewChecksumReader(file)
+	if err := store.Put(ctx, internalFilename, reader, fileHeader.Size); err != nil {
+		return "", err
+	}
+
+	return reader.Checksum(), nil
+}
+
+// validateStoredFile checks if the file stored under the given internal filename meets the criteria of the given
+// policy, returning its MIME type and size.
+func validateStoredFile(ctx context.Context, store storage.Storage, internalFilename, originalName string, policy *models.Policy) (string, int64, error) {
+	file, err := store.Get(ctx, internalFilename)
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot open stored file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("cannot stat stored file: %w", err)
+	}
+
+	mimeType, err := utils.ValidateStoredFile(file, info.Size(), originalName, policy)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return mimeType, info.Size(), nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetGuildStorage returns the storage taken up by the sounds of a given guild against its quota, in total and by
+// user.
+func (h *Handler) GetGuildStorage(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(guildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	used, users, err := h.db.GetGuildStorageUsage(guildID, h.trashCountsTowardQuota)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"storage": &models.GuildStorageUsage{
+			StorageUsage:   models.StorageUsage{Used: used, Quota: policy.MaxGuildStorage},
+			MaxUserStorage: policy.MaxUserStorage,
+			Users:          users,
+		},
+	})
+}
+
+// getStorageUsage returns the storage taken up by a user's sounds in a guild against their quota under the given
+// policy.
+func (h *Handler) getStorageUsage(guildID, userID int64, policy *models.Policy) (*models.StorageUsage, error) {
+	used, err := h.db.GetUserStorageUsage(guildID, userID, h.trashCountsTowardQuota)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StorageUsage{
+		Used:  used,
+		Quota: h.storageQuota(guildID, userID, policy).MaxUserStorage,
+	}, nil
+}
+
+// storageQuota returns the storage quota of a user's sounds in a guild under the given policy. The library is only
+// limited by the guild's quota, and global sounds only by the user's, as they don't belong to any guild.
+func (h *Handler) storageQuota(guildID, userID int64, policy *models.Policy) *models.StorageQuota {
+	quota := &models.StorageQuota{
+		MaxUserStorage:  policy.MaxUserStorage,
+		MaxGuildStorage: policy.MaxGuildStorage,
+		IncludeTrashed:  h.trashCountsTowardQuota,
+	}
+
+	if userID == utils.LibraryUserID {
+		quota.MaxUserStorage = utils.NoStorageLimit
+	}
+
+	if guildID == utils.GlobalGuildID {
+		quota.MaxGuildStorage = utils.NoStorageLimit
+	}
+
+	return quota
+}
+
+// storageQuotaError returns an error telling the user which quota was reached if err is a storage quota error from
+// the database, and nil otherwise.
+func storageQuotaError(err error, quota *models.StorageQuota) error {
+	switch {
+	case errors.Is(err, database.ErrUserStorageExceeded):
+		return fmt.Errorf("storage quota of %d bytes reached", quota.MaxUserStorage)
+	case errors.Is(err, database.ErrGuildStorageExceeded):
+		return fmt.Errorf("guild storage quota of %d bytes reached", quota.MaxGuildStorage)
+	default:
+		return nil
+	}
+}
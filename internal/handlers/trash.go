@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// GetUserTrash retrieves the sounds a user has deleted in a guild that can still be restored.
+func (h *Handler) GetUserTrash(c *gin.Context) {
+	guildID, userID, err := utils.GetUserGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.getTrash(c, guildID, userID)
+}
+
+// GetLibraryTrash retrieves the sounds deleted from a guild's library that can still be restored.
+func (h *Handler) GetLibraryTrash(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.getTrash(c, guildID, utils.LibraryUserID)
+}
+
+// RestoreSound takes one of the caller's sounds out of the trash.
+func (h *Handler) RestoreSound(c *gin.Context) {
+	h.restoreSound(c, c.Param("soundId"))
+}
+
+// RestoreLibrarySound takes a sound out of the guild library's trash.
+func (h *Handler) RestoreLibrarySound(c *gin.Context) {
+	guildID, err := utils.GetGuildID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sound, err := h.db.GetSoundIncludingTrashed(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	if owner.GuildID != guildID || owner.UserID != utils.LibraryUserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound is not in the guild library"})
+		return
+	}
+
+	h.restoreSound(c, sound.ID)
+}
+
+func (h *Handler) getTrash(c *gin.Context, guildID, userID int64) {
+	sounds, err := h.db.GetTrashedSounds(guildID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sounds": sounds,
+	})
+}
+
+// restoreSound takes a sound out of the trash, provided its owner has room for it again.
+func (h *Handler) restoreSound(c *gin.Context, soundID string) {
+	sound, err := h.db.GetSoundIncludingTrashed(soundID)
+	if err != nil || !sound.DeletedAt.Valid {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found in trash"})
+		return
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return
+	}
+
+	policy, err := h.getGuildPolicy(owner.GuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return
+	}
+
+	// trashed sounds already take up room if they count towards the limit
+	if !h.trashCountsTowardQuota {
+		maxSounds := policy.MaxFilesPerUser
+		if owner.UserID == utils.LibraryUserID {
+			maxSounds = utils.MaxLibrarySounds
+		}
+
+		currentSoundCount, err := h.db.CountSoundsByUser(owner.GuildID, owner.UserID, false)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch current sounds"})
+			return
+		}
+
+		if currentSoundCount >= int64(maxSounds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("maximum file limit of %d reached", maxSounds)})
+			return
+		}
+	}
+
+	quota := h.storageQuota(owner.GuildID, owner.UserID, policy)
+
+	sound, err = h.db.RestoreSound(soundID, quota)
+	if quotaErr := storageQuotaError(err, quota); quotaErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": quotaErr.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found in trash"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore sound"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sound": sound,
+	})
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"gorm.io/gorm"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ReplaceSoundFile replaces the audio of a sound while keeping its ID, so that settings and other references to it
+// keep working. The previous audio is kept as a version that can be rolled back to.
+func (h *Handler) ReplaceSoundFile(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	sound, policy, quota, status := h.getSoundForNewVersion(c)
+	if sound == nil {
+		return
+	}
+
+	mimeType, err := utils.ValidateFileUpload(file, policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file validation failed: %s", err)})
+		return
+	}
+
+	fileID, err := gonanoid.New()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate file ID"})
+		return
+	}
+
+	internalFilename := utils.GenerateInternalFilename(fileID, mimeType)
+
+	var checksum string
+
+	err = storeNewFile(h.db, internalFilename, func() (err error) {
+		checksum, err = saveUploadedFile(c.Request.Context(), h.store, file, internalFilename)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	soundFile := &models.SoundFile{
+		OriginalName:     file.Filename,
+		InternalFilename: internalFilename,
+		MimeType:         mimeType,
+		Size:             file.Size,
+		Checksum:         checksum,
+	}
+
+	sound, err = h.db.ReplaceSoundFile(sound.ID, soundFile, status, quota)
+	settleNewFile(c.Request.Context(), h.db, h.store, internalFilename, err == nil)
+
+	if quotaErr := storageQuotaError(err, quota); quotaErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": quotaErr.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace sound"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sound": sound,
+	})
+}
+
+// GetSoundVersions returns the previous versions of a sound that are still kept.
+func (h *Handler) GetSoundVersions(c *gin.Context) {
+	versions, err := h.db.GetSoundVersions(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound versions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+	})
+}
+
+// RollbackSound makes a previous version of a sound's audio current again.
+func (h *Handler) RollbackSound(c *gin.Context) {
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	sound, policy, quota, status := h.getSoundForNewVersion(c)
+	if sound == nil {
+		return
+	}
+
+	version, err := h.db.GetSoundVersion(sound.ID, versionNumber)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound version not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound version"})
+		return
+	}
+
+	// the policy may have been tightened since the version was uploaded
+	_, _, err = validateStoredFile(c.Request.Context(), h.store, version.InternalFilename, version.OriginalName, policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file validation failed: %s", err)})
+		return
+	}
+
+	// versions aren't scrubbed, so the file is checked before it becomes current again
+	if version.Checksum != "" {
+		checksum, err := storage.Checksum(c.Request.Context(), h.store, version.InternalFilename)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check sound version file"})
+			return
+		}
+
+		if checksum != version.Checksum {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Sound version file is corrupt"})
+			return
+		}
+	}
+
+	sound, err = h.db.RollbackSound(sound.ID, versionNumber, status, quota)
+	if quotaErr := storageQuotaError(err, quota); quotaErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": quotaErr.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back sound"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sound": sound,
+	})
+}
+
+// getSoundForNewVersion retrieves the requested sound along with the policy that new audio for it must meet, the
+// storage quota of its owner, and the review status the sound goes into. It writes an error response and returns a nil
+// sound if the audio can't be changed.
+func (h *Handler) getSoundForNewVersion(c *gin.Context) (*models.Sound, *models.Policy, *models.StorageQuota, string) {
+	sound, err := h.db.GetSoundByID(c.Param("soundId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+		return nil, nil, nil, ""
+	}
+
+	owner, err := h.db.GetUserByUserGuildID(sound.UserGuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sound owner"})
+		return nil, nil, nil, ""
+	}
+
+	ban, err := h.db.GetActiveBan(owner.GuildID, owner.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ban status"})
+		return nil, nil, nil, ""
+	}
+
+	if ban != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from using join sounds in this guild"})
+		return nil, nil, nil, ""
+	}
+
+	policy, err := h.getGuildPolicy(owner.GuildID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve guild policy"})
+		return nil, nil, nil, ""
+	}
+
+	status := utils.SoundStatusApproved
+	if policy.RequireApproval {
+		status = utils.SoundStatusPending
+	}
+
+	return sound, policy, h.storageQuota(owner.GuildID, owner.UserID, policy), status
+}
@@ -0,0 +1,2 @@
+// Package jobs provides background jobs that keep the database and stored files tidy.
+package jobs
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+)
+
+// RemoveScheduledFiles returns a job that removes the files whose scheduled removal is due: those of deleted records
+// whose removal failed, and those left behind by uploads that never wrote their sound's record.
+func RemoveScheduledFiles(db *database.DB, store storage.Storage) Job {
+	return func(ctx context.Context) error {
+		removals, err := db.GetDueFileRemovals(time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to fetch due file removals: %w", err)
+		}
+
+		filenames := make([]string, 0, len(removals))
+		for _, removal := range removals {
+			filenames = append(filenames, removal.InternalFilename)
+		}
+
+		return db.RemoveFiles(ctx, store, filenames)
+	}
+}
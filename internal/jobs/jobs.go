@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Job is a unit of background work that is run periodically.
+type Job func(ctx context.Context) error
+
+// Run runs the job every interval until the context is cancelled, logging any errors. The job also runs once
+// straight away.
+func Run(ctx context.Context, name string, interval time.Duration, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
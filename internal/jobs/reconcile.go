@@ -0,0 +1,182 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// ReconcileOptions controls what a reconciliation of storage does about the drift it finds.
+type ReconcileOptions struct {
+	Fix          bool          // the drift is only reported unless set
+	OrphanAction string        // utils.OrphanActionDelete or utils.OrphanActionQuarantine
+	GracePeriod  time.Duration // files younger than this are never orphaned, in case something is still writing them
+}
+
+// Validate checks that the options are usable.
+func (o *ReconcileOptions) Validate() error {
+	if o.OrphanAction != utils.OrphanActionDelete && o.OrphanAction != utils.OrphanActionQuarantine {
+		return fmt.Errorf("orphan action must be %s or %s", utils.OrphanActionDelete, utils.OrphanActionQuarantine)
+	}
+
+	if o.GracePeriod < 0 {
+		return errors.New("grace period must not be negative")
+	}
+
+	return nil
+}
+
+// ReconcileStorage returns a job that reconciles storage with the sound records and logs the drift it finds.
+func ReconcileStorage(db *database.DB, store storage.Storage, options *ReconcileOptions) Job {
+	return func(ctx context.Context) error {
+		report, err := Reconcile(ctx, db, store, options)
+		if err != nil {
+			return err
+		}
+
+		if len(report.OrphanFiles) > 0 || len(report.MissingFiles) > 0 || len(report.RecoveredSounds) > 0 ||
+			len(report.UnsizedSounds) > 0 {
+			log.Printf("Storage reconciliation found %d orphaned files, %d sounds with missing files, %d recovered "+
+				"sounds and %d sounds without a size (fixed: %t)", len(report.OrphanFiles), len(report.MissingFiles),
+				len(report.RecoveredSounds), len(report.UnsizedSounds), report.Fixed)
+		}
+
+		return nil
+	}
+}
+
+// Reconcile finds the drift between the sound records and the stored files: files that nothing refers to, and sounds
+// whose file is missing or has come back. When fixing, orphaned files are deleted or quarantined, and sounds are marked
+// broken or no longer broken, hiding them from listings and playback while broken. The size of sounds uploaded before
+// sizes were recorded is filled in from storage too.
+func Reconcile(ctx context.Context, db *database.DB, store storage.Storage, options *ReconcileOptions) (*models.ReconcileReport, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	// the sounds are read before listing the files, so that a sound uploaded in between is never seen without its
+	// file, and the known files after, as every new file is scheduled for removal before it is written
+	sounds, err := db.GetSoundFiles()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sounds: %w", err)
+	}
+
+	files, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+
+	known, err := db.GetKnownFilenames()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch known files: %w", err)
+	}
+
+	report := &models.ReconcileReport{
+		OrphanFiles:     make([]string, 0),
+		MissingFiles:    make([]string, 0),
+		RecoveredSounds: make([]string, 0),
+		UnsizedSounds:   make([]string, 0),
+	}
+
+	stored := make(map[string]fs.FileInfo, len(files))
+	cutoff := time.Now().Add(-options.GracePeriod)
+
+	for _, file := range files {
+		stored[file.Name()] = file
+
+		if !known[file.Name()] && !strings.HasPrefix(file.Name(), utils.QuarantinePrefix) && file.ModTime().Before(cutoff) {
+			report.OrphanFiles = append(report.OrphanFiles, file.Name())
+		}
+	}
+
+	sizes := make(map[string]int64)
+
+	for _, sound := range sounds {
+		file, exists := stored[sound.InternalFilename]
+		if !exists {
+			// it may have been removed along with its sound since the records were read
+			exists, err = fileExists(ctx, store, sound.InternalFilename)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if !exists {
+			report.MissingFiles = append(report.MissingFiles, sound.ID)
+			continue
+		}
+
+		if sound.Broken {
+			report.RecoveredSounds = append(report.RecoveredSounds, sound.ID)
+		}
+
+		// a file found by fileExists isn't in the listing, its size is left for the next run
+		if sound.Size == 0 && file != nil {
+			report.UnsizedSounds = append(report.UnsizedSounds, sound.ID)
+			sizes[sound.ID] = file.Size()
+		}
+	}
+
+	if !options.Fix {
+		return report, nil
+	}
+
+	if err := db.SetSoundsBroken(report.MissingFiles, true); err != nil {
+		return nil, err
+	}
+
+	if err := db.SetSoundsBroken(report.RecoveredSounds, false); err != nil {
+		return nil, err
+	}
+
+	for _, id := range report.UnsizedSounds {
+		if err := db.SetSoundSize(id, sizes[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, filename := range report.OrphanFiles {
+		if err := removeOrphan(ctx, store, filename, options.OrphanAction); err != nil {
+			return nil, err
+		}
+	}
+
+	report.Fixed = true
+	report.OrphanAction = options.OrphanAction
+
+	return report, nil
+}
+
+func fileExists(ctx context.Context, store storage.Storage, filename string) (bool, error) {
+	_, err := store.Stat(ctx, filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check file %s: %w", filename, err)
+	}
+
+	return true, nil
+}
+
+func removeOrphan(ctx context.Context, store storage.Storage, filename, action string) error {
+	if action == utils.OrphanActionQuarantine {
+		if err := store.Copy(ctx, filename, utils.QuarantinePrefix+filename); err != nil {
+			return fmt.Errorf("failed to quarantine %s: %w", filename, err)
+		}
+	}
+
+	if err := store.Delete(ctx, filename); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", filename, err)
+	}
+
+	return nil
+}
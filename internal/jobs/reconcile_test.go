@@ -0,0 +1,88 @@
+package jobs_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/jobs"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	db := databasetest.New(t)
+
+	store, err := storage.NewLocal(filepath.Join(dir, "sounds"))
+	require.NoError(t, err)
+
+	const guildID, userID = 1, 10
+
+	for _, name := range []string{"present.mp3", "orphan.mp3", "recent.mp3"} {
+		require.NoError(t, store.Put(ctx, name, strings.NewReader("sound"), 5))
+	}
+
+	old := time.Now().Add(-2 * utils.DefaultOrphanGracePeriod)
+	for _, name := range []string{"present.mp3", "orphan.mp3"} {
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "sounds", name), old, old))
+	}
+
+	present := databasetest.CreateSound(t, db, guildID, userID, "present.mp3")
+	missing := databasetest.CreateSound(t, db, guildID, userID, "missing.mp3")
+
+	options := &jobs.ReconcileOptions{OrphanAction: utils.OrphanActionQuarantine, GracePeriod: utils.DefaultOrphanGracePeriod}
+
+	report, err := jobs.Reconcile(ctx, db, store, options)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orphan.mp3"}, report.OrphanFiles)
+	assert.Equal(t, []string{missing.ID}, report.MissingFiles)
+	assert.Equal(t, []string{present.ID}, report.UnsizedSounds)
+	assert.False(t, report.Fixed)
+
+	// nothing changes without fixing
+	sounds, err := db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	assert.Len(t, sounds, 2)
+
+	options.Fix = true
+
+	report, err = jobs.Reconcile(ctx, db, store, options)
+	require.NoError(t, err)
+	assert.True(t, report.Fixed)
+
+	sounds, err = db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	require.Len(t, sounds, 1)
+	assert.Equal(t, present.ID, sounds[0].ID)
+	assert.Equal(t, int64(5), sounds[0].Size)
+
+	_, err = store.Stat(ctx, "orphan.mp3")
+	require.Error(t, err)
+
+	_, err = store.Stat(ctx, utils.QuarantinePrefix+"orphan.mp3")
+	require.NoError(t, err)
+
+	// once the file is back, the sound is no longer broken
+	require.NoError(t, store.Put(ctx, "missing.mp3", strings.NewReader("sound"), 5))
+
+	report, err = jobs.Reconcile(ctx, db, store, options)
+	require.NoError(t, err)
+	assert.Empty(t, report.OrphanFiles)
+	assert.Empty(t, report.MissingFiles)
+	assert.Equal(t, []string{missing.ID}, report.RecoveredSounds)
+
+	sounds, err = db.GetSoundsByUser(guildID, userID)
+	require.NoError(t, err)
+	assert.Len(t, sounds, 2)
+}
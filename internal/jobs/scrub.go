@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+)
+
+// ScrubStorage returns a job that checks the files of up to batchSize sounds against their checksums each time it
+// runs, those checked longest ago first, so that every file is checked in turn. It logs any corruption it finds.
+func ScrubStorage(db *database.DB, store storage.Storage, batchSize int) Job {
+	return func(ctx context.Context) error {
+		sounds, err := db.GetSoundsToScrub(batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to fetch sounds to scrub: %w", err)
+		}
+
+		report, err := Scrub(ctx, db, store, sounds)
+
+		if len(report.CorruptSounds) > 0 || len(report.RepairedSounds) > 0 {
+			log.Printf("Storage scrub of %d files found %d corrupt sounds and %d repaired sounds: %v",
+				report.Checked, len(report.CorruptSounds), len(report.RepairedSounds), report.CorruptSounds)
+		}
+
+		return err
+	}
+}
+
+// Scrub checks the files of the given sounds against their checksums, flagging the sounds whose file no longer
+// matches as corrupt, which keeps them from being played, and clearing the flag of those that match again, e.g. after
+// being restored from a backup. Sounds uploaded before checksums were recorded have theirs recorded instead. Missing
+// files are skipped, as they are found by Reconcile. Every sound is checked even if some fail to be, and the report
+// covers those that were.
+func Scrub(ctx context.Context, db *database.DB, store storage.Storage, sounds []*models.Sound) (*models.ScrubReport, error) {
+	report := &models.ScrubReport{
+		CorruptSounds:  []string{},
+		RepairedSounds: []string{},
+		RecordedSounds: []string{},
+		MissingSounds:  []string{},
+	}
+
+	var errs []error
+
+	for _, sound := range sounds {
+		if err := ctx.Err(); err != nil {
+			return report, errors.Join(append(errs, err)...)
+		}
+
+		checksum, err := storage.Checksum(ctx, store, sound.InternalFilename)
+		if errors.Is(err, fs.ErrNotExist) {
+			report.MissingSounds = append(report.MissingSounds, sound.ID)
+			continue
+		} else if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		report.Checked++
+
+		if sound.Checksum == "" {
+			if err := db.RecordScrub(sound, checksum, false); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			report.RecordedSounds = append(report.RecordedSounds, sound.ID)
+
+			continue
+		}
+
+		corrupt := checksum != sound.Checksum
+
+		// the checksum recorded at upload is kept, so that a repaired file is recognised
+		if err := db.RecordScrub(sound, sound.Checksum, corrupt); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		switch {
+		case corrupt:
+			report.CorruptSounds = append(report.CorruptSounds, sound.ID)
+		case sound.Corrupt:
+			report.RepairedSounds = append(report.RepairedSounds, sound.ID)
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package jobs_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/database/databasetest"
+	"github.com/mocbotau/api-join-sound/internal/jobs"
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestScrub(t *testing.T) {
+	t.Parallel()
+
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	db := databasetest.New(t)
+
+	store, err := storage.NewLocal(filepath.Join(dir, "sounds"))
+	require.NoError(t, err)
+
+	const guildID, userID = 1, 10
+
+	checksum, err := utils.Checksum(strings.NewReader("sound"))
+	require.NoError(t, err)
+
+	for _, name := range []string{"intact.mp3", "legacy.mp3"} {
+		require.NoError(t, store.Put(ctx, name, strings.NewReader("sound"), 5))
+	}
+
+	// a flipped byte on disk
+	require.NoError(t, store.Put(ctx, "damaged.mp3", strings.NewReader("sounc"), 5))
+
+	create := func(name, checksum string) *models.Sound {
+		file := &models.SoundFile{OriginalName: name, InternalFilename: name, MimeType: "audio/mpeg", Size: 5, Checksum: checksum}
+
+		return databasetest.CreateSoundWithFile(t, db, guildID, userID, file, utils.SoundStatusApproved)
+	}
+
+	intact := create("intact.mp3", checksum)
+	legacy := create("legacy.mp3", "")
+	damaged := create("damaged.mp3", checksum)
+	missing := create("missing.mp3", checksum)
+
+	_, err = db.UpdateUserSetting(damaged.UserGuildID, &models.UpdateSettingsRequest{ActiveSoundID: &damaged.ID})
+	require.NoError(t, err)
+
+	sounds, err := db.GetSoundsToScrub(10)
+	require.NoError(t, err)
+	require.Len(t, sounds, 4)
+
+	report, err := jobs.Scrub(ctx, db, store, sounds)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Checked)
+	assert.Equal(t, []string{damaged.ID}, report.CorruptSounds)
+	assert.Equal(t, []string{legacy.ID}, report.RecordedSounds)
+	assert.Equal(t, []string{missing.ID}, report.MissingSounds)
+
+	stored, err := db.GetSoundByID(legacy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, checksum, stored.Checksum)
+
+	stored, err = db.GetSoundByID(intact.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Corrupt)
+	assert.NotNil(t, stored.ScrubbedAt)
+
+	// corrupt sounds are never played
+	sound, _, err := db.ResolveSound(guildID, userID)
+	require.NoError(t, err)
+	assert.Nil(t, sound)
+
+	integrity, err := db.GetIntegrityReport()
+	require.NoError(t, err)
+	require.Len(t, integrity.CorruptSounds, 1)
+	assert.Equal(t, damaged.ID, integrity.CorruptSounds[0].Sound.ID)
+	assert.Equal(t, int64(userID), integrity.CorruptSounds[0].UserID)
+	assert.Equal(t, int64(1), integrity.Unscrubbed)
+
+	// the missing file was never checked, so it comes first in the next batch
+	sounds, err = db.GetSoundsToScrub(1)
+	require.NoError(t, err)
+	require.Len(t, sounds, 1)
+	assert.Equal(t, missing.ID, sounds[0].ID)
+
+	// once the file is restored from a backup, the sound is playable again
+	require.NoError(t, store.Put(ctx, "damaged.mp3", strings.NewReader("sound"), 5))
+
+	sounds, err = db.GetUserSoundsToScrub(userID)
+	require.NoError(t, err)
+
+	report, err = jobs.Scrub(ctx, db, store, sounds)
+	require.NoError(t, err)
+	assert.Empty(t, report.CorruptSounds)
+	assert.Equal(t, []string{damaged.ID}, report.RepairedSounds)
+
+	sound, _, err = db.ResolveSound(guildID, userID)
+	require.NoError(t, err)
+	require.NotNil(t, sound)
+	assert.Equal(t, damaged.ID, sound.ID)
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+)
+
+// PurgeTrash returns a job that permanently deletes the sounds that have been in the trash for longer than the
+// retention period, along with their files and versions. The removal of the files is scheduled along with deleting the
+// rows, so that a file that fails to be removed is retried by RemoveScheduledFiles.
+func PurgeTrash(db *database.DB, store storage.Storage, retention time.Duration) Job {
+	return func(ctx context.Context) error {
+		sounds, err := db.GetExpiredTrashedSounds(time.Now().Add(-retention))
+		if err != nil {
+			return fmt.Errorf("failed to fetch expired trashed sounds: %w", err)
+		}
+
+		var errs []error
+
+		for _, sound := range sounds {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			filenames := []string{sound.InternalFilename}
+			for _, version := range sound.Versions {
+				filenames = append(filenames, version.InternalFilename)
+			}
+
+			if err := db.PurgeSound(sound.ID); err != nil {
+				return err
+			}
+
+			if err := db.RemoveFiles(ctx, store, filenames); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+}
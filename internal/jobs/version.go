@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/storage"
+)
+
+// PurgeSoundVersions returns a job that deletes the sound versions that were replaced longer than the retention
+// period ago, along with their files. The removal of the files is scheduled along with deleting the rows, so that a
+// file that fails to be removed is retried by RemoveScheduledFiles.
+func PurgeSoundVersions(db *database.DB, store storage.Storage, retention time.Duration) Job {
+	return func(ctx context.Context) error {
+		versions, err := db.GetExpiredSoundVersions(time.Now().Add(-retention))
+		if err != nil {
+			return fmt.Errorf("failed to fetch expired sound versions: %w", err)
+		}
+
+		var errs []error
+
+		for _, version := range versions {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if err := db.DeleteSoundVersion(version.ID); err != nil {
+				return err
+			}
+
+			if err := db.RemoveFiles(ctx, store, []string{version.InternalFilename}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return errors.Join(errs...)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,11 +20,29 @@ import (
 
 	"github.com/mocbotau/api-join-sound/internal/database"
 	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
 )
 
 // CustomClaims contains custom data we want from the token..
 type CustomClaims struct {
-	Scope string `json:"scope"`
+	Scope       string   `json:"scope"`
+	Guilds      []string `json:"guilds"`       // guilds the user is a member of, added by an Auth0 action
+	AdminGuilds []string `json:"admin_guilds"` // guilds the user administers, added by an Auth0 action
+}
+
+// HasScope checks whether the token was granted the given scope.
+func (c CustomClaims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope)
+}
+
+// IsGuildMember checks whether the token asserts that the user is a member of the given guild.
+func (c CustomClaims) IsGuildMember(guildID int64) bool {
+	return slices.Contains(c.Guilds, strconv.FormatInt(guildID, 10)) || c.IsGuildAdmin(guildID)
+}
+
+// IsGuildAdmin checks whether the token asserts that the user administers the given guild.
+func (c CustomClaims) IsGuildAdmin(guildID int64) bool {
+	return slices.Contains(c.AdminGuilds, strconv.FormatInt(guildID, 10))
 }
 
 // Validate does nothing for this example, but we need.
@@ -34,6 +53,17 @@ func (c CustomClaims) Validate(_ context.Context) error {
 
 // EnsureValidToken is a middleware that will check the validity of our JWT..
 func EnsureValidToken() gin.HandlerFunc {
+	return validateToken()
+}
+
+// AllowValidToken is a middleware that checks the validity of the JWT if the request has one, for public routes that
+// give authenticated callers more. Requests without a token are let through.
+func AllowValidToken() gin.HandlerFunc {
+	return validateToken(jwtmiddleware.WithCredentialsOptional(true))
+}
+
+// validateToken creates the middleware that checks the validity of our JWT with the given options.
+func validateToken(options ...jwtmiddleware.Option) gin.HandlerFunc {
 	issuerURL, err := url.Parse("https://" + os.Getenv("AUTH0_DOMAIN") + "/")
 	if err != nil {
 		log.Fatalf("Failed to parse the issuer url: %v", err)
@@ -67,20 +97,29 @@ func EnsureValidToken() gin.HandlerFunc {
 
 	middleware := jwtmiddleware.New(
 		jwtValidator.ValidateToken,
-		jwtmiddleware.WithErrorHandler(errorHandler),
+		append([]jwtmiddleware.Option{jwtmiddleware.WithErrorHandler(errorHandler)}, options...)...,
 	)
 
 	return adapter.Wrap(middleware.CheckJWT)
 }
 
-// ExtractUserIDFromJWT extracts the Discord user ID from the JWT token's subject field.
-func extractUserIDFromJWT(c *gin.Context) (int64, error) {
+// extractClaimsFromJWT extracts the validated claims of the JWT token from the request context.
+func extractClaimsFromJWT(c *gin.Context) (*validator.ValidatedClaims, error) {
 	token := c.Request.Context().Value(jwtmiddleware.ContextKey{})
 	if token == nil {
-		return 0, fmt.Errorf("no token found in context")
+		return nil, fmt.Errorf("no token found in context")
+	}
+
+	return token.(*validator.ValidatedClaims), nil
+}
+
+// ExtractUserIDFromJWT extracts the Discord user ID from the JWT token's subject field.
+func extractUserIDFromJWT(c *gin.Context) (int64, error) {
+	validatedClaims, err := extractClaimsFromJWT(c)
+	if err != nil {
+		return 0, err
 	}
 
-	validatedClaims := token.(*validator.ValidatedClaims)
 	subject := validatedClaims.RegisteredClaims.Subject
 
 	parts := strings.Split(subject, "|")
@@ -133,6 +172,17 @@ func EnsureUserAuthorization() gin.HandlerFunc {
 
 // EnsureResourceOwnership checks ownership for resource-based routes (e.g., sound ID).
 func EnsureResourceOwnership(db *database.DB) gin.HandlerFunc {
+	return ensureResourceOwnership(db, db.GetSoundByID)
+}
+
+// EnsureTrashedResourceOwnership checks ownership like EnsureResourceOwnership, but also of sounds in the trash, so
+// that their owners can restore them.
+func EnsureTrashedResourceOwnership(db *database.DB) gin.HandlerFunc {
+	return ensureResourceOwnership(db, db.GetSoundIncludingTrashed)
+}
+
+// ensureResourceOwnership creates the middleware that checks ownership of the sound found by getSound.
+func ensureResourceOwnership(db *database.DB, getSound func(id string) (*models.Sound, error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		jwtUserID, err := extractUserIDFromJWT(c)
 		if err != nil {
@@ -143,7 +193,7 @@ func EnsureResourceOwnership(db *database.DB) gin.HandlerFunc {
 		}
 
 		if soundID := c.Param("soundId"); soundID != "" {
-			sound, err := db.GetSoundByID(soundID)
+			sound, err := getSound(soundID)
 			if err != nil {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
 				c.Abort()
@@ -172,3 +222,265 @@ func EnsureResourceOwnership(db *database.DB) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// EnsureBotAuthorization checks that the JWT was issued to the bot.
+func EnsureBotAuthorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validatedClaims, err := extractClaimsFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract claims from token"})
+			c.Abort()
+
+			return
+		}
+
+		customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims)
+		if !ok || !customClaims.HasScope(utils.BotScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint is only available to the bot"})
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// EnsureAdminAuthorization checks that the JWT was issued to an operator of the service. The token's subject is kept
+// for auditing.
+func EnsureAdminAuthorization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validatedClaims, err := extractClaimsFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract claims from token"})
+			c.Abort()
+
+			return
+		}
+
+		customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims)
+		if !ok || !customClaims.HasScope(utils.AdminScope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint is only available to admins"})
+			c.Abort()
+
+			return
+		}
+
+		c.Set("subject", validatedClaims.RegisteredClaims.Subject)
+		c.Next()
+	}
+}
+
+// EnsureGuildAdmin checks that the JWT user is an admin of the requested guild, either through a
+// token claim or because the bot has marked them as one. The bot itself is always allowed through.
+func EnsureGuildAdmin(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validatedClaims, err := extractClaimsFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract claims from token"})
+			c.Abort()
+
+			return
+		}
+
+		guildID, err := utils.GetGuildID(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guild ID parameter"})
+			c.Abort()
+
+			return
+		}
+
+		customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims)
+		if ok && customClaims.HasScope(utils.BotScope) {
+			c.Next()
+			return
+		}
+
+		jwtUserID, err := extractUserIDFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract user ID from token"})
+			c.Abort()
+
+			return
+		}
+
+		if !ok || !customClaims.IsGuildAdmin(guildID) {
+			isAdmin, err := db.IsGuildAdmin(guildID, jwtUserID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guild admin status"})
+				c.Abort()
+
+				return
+			}
+
+			if !isAdmin {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You must be an admin of this guild"})
+				c.Abort()
+
+				return
+			}
+		}
+
+		c.Set("userID", jwtUserID)
+		c.Next()
+	}
+}
+
+// EnsureGuildMembership checks that the JWT user is a member of the guild, either through a token claim, because
+// they are a guild admin, or because the bot has played their join sound there. The guild is taken from the guild
+// ID parameter, or otherwise from the owner of the requested sound. Global sounds have no guild of their own, so for
+// them it is taken from the guild_id query parameter.
+func EnsureGuildMembership(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validatedClaims, err := extractClaimsFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract claims from token"})
+			c.Abort()
+
+			return
+		}
+
+		jwtUserID, err := extractUserIDFromJWT(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to extract user ID from token"})
+			c.Abort()
+
+			return
+		}
+
+		var guildID int64
+
+		if c.Param("guildId") != "" {
+			if guildID, err = utils.GetGuildID(c); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guild ID parameter"})
+				c.Abort()
+
+				return
+			}
+		} else {
+			sound, err := db.GetSoundByID(c.Param("soundId"))
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Sound not found"})
+				c.Abort()
+
+				return
+			}
+
+			owner, err := db.GetUserByUserGuildID(sound.UserGuildID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guild membership"})
+				c.Abort()
+
+				return
+			}
+
+			guildID = owner.GuildID
+
+			// global sounds are played in every guild, so the caller names the guild they heard it in
+			if guildID == utils.GlobalGuildID {
+				guildID, err = strconv.ParseInt(c.Query("guild_id"), 10, 64)
+				if err != nil || guildID == utils.GlobalGuildID {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "A guild_id query parameter is required for global sounds"})
+					c.Abort()
+
+					return
+				}
+			}
+		}
+
+		isMember, err := isGuildMember(db, validatedClaims, guildID, jwtUserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guild membership"})
+			c.Abort()
+
+			return
+		}
+
+		if !isMember {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You must be a member of this guild"})
+			c.Abort()
+
+			return
+		}
+
+		c.Set("userID", jwtUserID)
+		c.Set("guildID", guildID)
+		c.Next()
+	}
+}
+
+// IdentifyGuildMember marks requests whose JWT user is the requested user or a member of the requested guild, as in
+// EnsureGuildMembership, on public routes behind AllowValidToken. Other requests are let through unmarked.
+func IdentifyGuildMember(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		validatedClaims, err := extractClaimsFromJWT(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		jwtUserID, err := extractUserIDFromJWT(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		guildID, err := utils.GetGuildID(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		isMember := c.Param("userId") == strconv.FormatInt(jwtUserID, 10)
+
+		if !isMember {
+			if isMember, err = isGuildMember(db, validatedClaims, guildID, jwtUserID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify guild membership"})
+				c.Abort()
+
+				return
+			}
+		}
+
+		c.Set("userID", jwtUserID)
+		c.Set("guildMember", isMember)
+		c.Next()
+	}
+}
+
+// IsGuildMember checks whether the JWT user of an authenticated request is a member of the given guild, for handlers
+// that act on a guild given in the request body rather than in the route.
+func IsGuildMember(c *gin.Context, db *database.DB, guildID int64) (bool, error) {
+	validatedClaims, err := extractClaimsFromJWT(c)
+	if err != nil {
+		return false, err
+	}
+
+	jwtUserID, err := extractUserIDFromJWT(c)
+	if err != nil {
+		return false, err
+	}
+
+	return isGuildMember(db, validatedClaims, guildID, jwtUserID)
+}
+
+// isGuildMember checks whether the token asserts that the user is a member of the guild, or else whether we have seen
+// them there.
+func isGuildMember(db *database.DB, validatedClaims *validator.ValidatedClaims, guildID, userID int64) (bool, error) {
+	if customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims); ok && customClaims.IsGuildMember(guildID) {
+		return true, nil
+	}
+
+	return isKnownGuildMember(db, guildID, userID)
+}
+
+// isKnownGuildMember checks whether we have seen the user in the guild, without relying on token claims.
+func isKnownGuildMember(db *database.DB, guildID, userID int64) (bool, error) {
+	isAdmin, err := db.IsGuildAdmin(guildID, userID)
+	if err != nil || isAdmin {
+		return isAdmin, err
+	}
+
+	return db.HasPlayedInGuild(guildID, userID)
+}
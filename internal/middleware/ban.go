@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/database"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// EnsureNotBanned rejects requests for a user who is banned from join sounds in the requested guild.
+func EnsureNotBanned(db *database.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guildID, userID, err := utils.GetUserGuildID(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.Abort()
+
+			return
+		}
+
+		ban, err := db.GetActiveBan(guildID, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ban status"})
+			c.Abort()
+
+			return
+		}
+
+		if ban != nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":      "You are banned from using join sounds in this guild",
+				"reason":     ban.Reason,
+				"expires_at": ban.ExpiresAt,
+			})
+			c.Abort()
+
+			return
+		}
+
+		c.Next()
+	}
+}
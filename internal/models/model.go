@@ -3,6 +3,8 @@ package models
 import (
 	"mime/multipart"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User represents a user in a specific guild.
@@ -17,15 +19,64 @@ type User struct {
 
 // Sound represents an uploaded sound file.
 type Sound struct {
+	ID               string         `json:"id" gorm:"type:text;primaryKey;not null"`
+	UserGuildID      string         `json:"user_guild_id" gorm:"type:text;not null;index"`
+	OriginalName     string         `json:"original_name" gorm:"type:text;not null"`
+	DisplayName      string         `json:"display_name" gorm:"type:text;not null;default:''"`
+	Emoji            string         `json:"emoji" gorm:"type:text;not null;default:''"`
+	Tags             []string       `json:"tags" gorm:"serializer:json"`
+	Description      string         `json:"description" gorm:"type:text;not null;default:''"`
+	InternalFilename string         `json:"-" gorm:"type:text;not null"` // we don't want to expose this to the user
+	MimeType         string         `json:"mime_type" gorm:"type:text;not null"`
+	Size             int64          `json:"size" gorm:"not null;default:0"` // in bytes, 0 if uploaded before sizes were recorded
+	CreatedAt        time.Time      `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	Status           string         `json:"status" gorm:"type:text;not null;default:'approved'"`
+	ReviewReason     *string        `json:"review_reason" gorm:"type:text"`
+	ReviewedBy       *int64         `json:"reviewed_by"`
+	ReviewedAt       *time.Time     `json:"reviewed_at"`
+	Hidden           bool           `json:"hidden" gorm:"not null;default:false"`          // hidden from playback after being reported
+	Broken           bool           `json:"broken" gorm:"not null;default:false"`          // its file is missing, see jobs.Reconcile
+	Checksum         string         `json:"checksum" gorm:"type:text;not null;default:''"` // SHA-256 of the file, '' if not yet known
+	Corrupt          bool           `json:"corrupt" gorm:"not null;default:false"`         // its file no longer matches the checksum, see jobs.Scrub
+	ScrubbedAt       *time.Time     `json:"scrubbed_at"`                                   // when the file was last checked against the checksum
+	PlayCount        int64          `json:"play_count" gorm:"-"`                           // populated from the plays table when listing
+	Scope            string         `json:"scope,omitempty" gorm:"-"`                      // populated when listing a user's sounds
+	Position         int            `json:"position" gorm:"not null;default:0"`            // sounds are listed by position, then newest first
+	Version          int            `json:"version" gorm:"not null;default:1"`
+	ReplacedAt       *time.Time     `json:"replaced_at"`                     // when the audio was last replaced
+	ClonedFromID     *string        `json:"cloned_from_id" gorm:"type:text"` // not a foreign key, the copy outlives the source
+	DeletedAt        gorm.DeletedAt `json:"deleted_at" gorm:"index"`         // set while the sound is in the trash
+	Download         *SignedURL     `json:"download,omitempty" gorm:"-"`     // populated for authenticated callers
+
+	User     User           `json:"-" gorm:"foreignKey:UserGuildID;references:ID"`
+	Settings []Setting      `json:"-" gorm:"foreignKey:ActiveSoundID"`
+	Versions []SoundVersion `json:"-" gorm:"foreignKey:SoundID"`
+}
+
+// AfterFind lists sounds without tags with an empty list of them rather than null.
+func (s *Sound) AfterFind(*gorm.DB) error {
+	if s.Tags == nil {
+		s.Tags = []string{}
+	}
+
+	return nil
+}
+
+// SoundVersion represents a previous version of a sound's audio, kept after it was replaced until the retention
+// period passes.
+type SoundVersion struct {
 	ID               string    `json:"id" gorm:"type:text;primaryKey;not null"`
-	UserGuildID      string    `json:"user_guild_id" gorm:"type:text;not null;index"`
+	SoundID          string    `json:"sound_id" gorm:"type:text;not null;uniqueIndex:idx_sound_versions_sound_version"`
+	Version          int       `json:"version" gorm:"not null;uniqueIndex:idx_sound_versions_sound_version"`
 	OriginalName     string    `json:"original_name" gorm:"type:text;not null"`
-	InternalFilename string    `json:"-" gorm:"type:text;not null"` // we don't want to expose this to the user
+	InternalFilename string    `json:"-" gorm:"type:text;not null"`
 	MimeType         string    `json:"mime_type" gorm:"type:text;not null"`
-	CreatedAt        time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	Size             int64     `json:"size" gorm:"not null;default:0"`
+	Checksum         string    `json:"checksum" gorm:"type:text;not null;default:''"`
+	CreatedAt        time.Time `json:"created_at" gorm:"not null"` // when this version was uploaded
+	ReplacedAt       time.Time `json:"replaced_at" gorm:"not null;index"`
 
-	User     User      `json:"-" gorm:"foreignKey:UserGuildID;references:ID"`
-	Settings []Setting `json:"-" gorm:"foreignKey:ActiveSoundID"`
+	Sound Sound `json:"-" gorm:"foreignKey:SoundID;references:ID;constraint:OnDelete:CASCADE"`
 }
 
 // Setting represents user settings for sound playback.
@@ -38,6 +89,224 @@ type Setting struct {
 	ActiveSound Sound `json:"-" gorm:"foreignKey:ActiveSoundID;references:ID"`
 }
 
+// Guild represents a Discord guild.
+type Guild struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	Settings *GuildSetting `json:"-" gorm:"foreignKey:GuildID"`
+	Admins   []GuildAdmin  `json:"-" gorm:"foreignKey:GuildID"`
+}
+
+// GuildSetting represents guild-wide settings, managed by the guild's admins.
+type GuildSetting struct {
+	GuildID         int64  `json:"guild_id" gorm:"primaryKey;autoIncrement:false"`
+	DefaultMode     string `json:"default_mode" gorm:"type:text;not null;default:'single';check:default_mode IN ('single', 'random')"`
+	RequireApproval bool   `json:"require_approval" gorm:"not null;default:false"`
+	Enabled         bool   `json:"enabled" gorm:"not null;default:true"`
+
+	// quiet hours as "HH:MM" in the guild's timezone, during which no sounds are played
+	QuietHoursStart *string `json:"quiet_hours_start" gorm:"type:text"`
+	QuietHoursEnd   *string `json:"quiet_hours_end" gorm:"type:text"`
+	Timezone        string  `json:"timezone" gorm:"type:text;not null;default:'UTC'"`
+
+	// sound played for users without one of their own, either a single sound or a random pick from the pool
+	DefaultSoundMode string   `json:"default_sound_mode" gorm:"type:text;not null;default:'single'"`
+	DefaultSoundID   *string  `json:"default_sound_id" gorm:"type:text"`
+	DefaultSoundPool []string `json:"default_sound_pool" gorm:"-"` // populated from the guild_default_sounds table
+
+	// number of open reports after which a sound is hidden from playback, 0 disables it
+	ReportThreshold int `json:"report_threshold" gorm:"not null;default:3"`
+
+	// policy overrides, nil means the global default applies
+	MaxAudioDurationMs *int64   `json:"max_audio_duration_ms"`
+	MaxFilesPerUser    *int     `json:"max_files_per_user"`
+	MaxUploadSize      *int64   `json:"max_upload_size"`
+	MaxUserStorage     *int64   `json:"max_user_storage"`
+	MaxGuildStorage    *int64   `json:"max_guild_storage"`
+	AllowedTypes       []string `json:"allowed_types" gorm:"serializer:json"`
+
+	Guild Guild `json:"-" gorm:"foreignKey:GuildID;references:ID"`
+}
+
+// GuildAdmin marks a user as an administrator of a guild.
+type GuildAdmin struct {
+	GuildID   int64     `json:"guild_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    int64     `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	Guild Guild `json:"-" gorm:"foreignKey:GuildID;references:ID"`
+}
+
+// GuildDefaultSound represents a sound in a guild's default pool, used in random mode.
+type GuildDefaultSound struct {
+	GuildID int64  `json:"guild_id" gorm:"primaryKey;autoIncrement:false"`
+	SoundID string `json:"sound_id" gorm:"type:text;primaryKey"`
+
+	Sound Sound `json:"-" gorm:"foreignKey:SoundID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Report represents a guild member flagging a sound as offensive.
+type Report struct {
+	ID         string     `json:"id" gorm:"type:text;primaryKey;not null"`
+	SoundID    string     `json:"sound_id" gorm:"type:text;not null;uniqueIndex:idx_reports_sound_reporter"`
+	GuildID    int64      `json:"guild_id" gorm:"not null;index"`
+	ReporterID int64      `json:"reporter_id" gorm:"not null;uniqueIndex:idx_reports_sound_reporter"`
+	Reason     string     `json:"reason" gorm:"type:text;not null"`
+	Status     string     `json:"status" gorm:"type:text;not null;default:'open';index"`
+	ResolvedBy *int64     `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	Sound Sound `json:"-" gorm:"foreignKey:SoundID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// ShareToken lets anyone holding it clone a sound into their own sounds, until it expires, is used up or is revoked.
+type ShareToken struct {
+	Token     string     `json:"token" gorm:"type:text;primaryKey;not null"`
+	SoundID   string     `json:"sound_id" gorm:"type:text;not null;index"`
+	CreatedBy int64      `json:"created_by" gorm:"not null"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil means the token never expires
+	SingleUse bool       `json:"single_use" gorm:"not null;default:false"`
+	Uses      int64      `json:"uses" gorm:"not null;default:0"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+
+	Sound Sound `json:"-" gorm:"foreignKey:SoundID;references:ID;constraint:OnDelete:CASCADE"`
+}
+
+// Ban prevents a user from using join sounds in a guild, without touching their data.
+type Ban struct {
+	GuildID   int64      `json:"guild_id" gorm:"primaryKey;autoIncrement:false"`
+	UserID    int64      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	Reason    *string    `json:"reason" gorm:"type:text"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"` // nil means the ban never expires
+	BannedBy  *int64     `json:"banned_by"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// UserDeletion records that a user's data was deleted across all guilds. It is kept after the data is gone.
+type UserDeletion struct {
+	ID          string    `json:"id" gorm:"type:text;primaryKey;not null"`
+	UserID      int64     `json:"user_id" gorm:"not null;index"`
+	RequestedBy string    `json:"requested_by" gorm:"type:text;not null"` // "self", or the token subject of the admin
+	Users       int64     `json:"users" gorm:"not null;default:0"`        // guild memberships, the global profile included
+	Sounds      int64     `json:"sounds" gorm:"not null;default:0"`
+	Settings    int64     `json:"settings" gorm:"not null;default:0"`
+	Plays       int64     `json:"plays" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+}
+
+// FileRemoval schedules the removal of a stored file, which goes ahead once RemoveAfter passes unless a sound or sound
+// version refers to the file by then. Uploads schedule one before writing their file, so that a file is never left
+// behind if the sound's record isn't written, and deletions schedule one along with deleting their records, so that a
+// failed removal is retried instead of lost.
+type FileRemoval struct {
+	InternalFilename string    `json:"internal_filename" gorm:"type:text;primaryKey;not null"`
+	RemoveAfter      time.Time `json:"remove_after" gorm:"not null;index"`
+	Attempts         int       `json:"attempts" gorm:"not null;default:0"` // failed attempts so far
+	LastError        string    `json:"last_error" gorm:"type:text;not null;default:''"`
+	CreatedAt        time.Time `json:"created_at" gorm:"not null"`
+}
+
+// SoundFile describes a stored audio file being given to a sound.
+type SoundFile struct {
+	OriginalName     string
+	InternalFilename string
+	MimeType         string
+	Size             int64
+	Checksum         string
+}
+
+// StorageQuota limits the total size of a user's sounds, and of every sound in their guild, in bytes. A negative limit
+// means there is no limit.
+type StorageQuota struct {
+	MaxUserStorage  int64
+	MaxGuildStorage int64
+	IncludeTrashed  bool // whether sounds in the trash take up storage
+}
+
+// StorageUsage represents the storage taken up by sounds against its quota, in bytes.
+type StorageUsage struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
+
+// GuildStorageUsage represents the storage taken up by the sounds of a guild, in total and by user.
+type GuildStorageUsage struct {
+	StorageUsage
+	MaxUserStorage int64               `json:"max_user_storage"`
+	Users          []*UserStorageUsage `json:"users"`
+}
+
+// UserStorageUsage represents the storage taken up by the sounds of a user in a guild. The guild's library is listed
+// under user ID 0.
+type UserStorageUsage struct {
+	UserID int64 `json:"user_id"`
+	Sounds int64 `json:"sounds"`
+	Used   int64 `json:"used"`
+}
+
+// ReconcileReport describes the drift between the sound records and the stored files found by a reconciliation, and
+// whether it was fixed.
+type ReconcileReport struct {
+	OrphanFiles     []string `json:"orphan_files"`     // stored files that nothing refers to
+	MissingFiles    []string `json:"missing_files"`    // IDs of the sounds whose file is missing
+	RecoveredSounds []string `json:"recovered_sounds"` // IDs of the broken sounds whose file is back
+	UnsizedSounds   []string `json:"unsized_sounds"`   // IDs of the sounds uploaded before their size was recorded
+	Fixed           bool     `json:"fixed"`
+	OrphanAction    string   `json:"orphan_action,omitempty"` // what was done with the orphaned files, if fixed
+}
+
+// ScrubReport describes the outcome of checking stored sound files against their checksums.
+type ScrubReport struct {
+	Checked        int      `json:"checked"`         // files hashed, whether or not their checksum was known
+	CorruptSounds  []string `json:"corrupt_sounds"`  // IDs of the sounds whose file no longer matches its checksum
+	RepairedSounds []string `json:"repaired_sounds"` // IDs of the corrupt sounds whose file matches its checksum again
+	RecordedSounds []string `json:"recorded_sounds"` // IDs of the sounds uploaded before checksums, now recorded
+	MissingSounds  []string `json:"missing_sounds"`  // IDs of the sounds whose file is missing, left to reconciliation
+}
+
+// IntegrityReport describes the state of the stored sound files' integrity, for operators.
+type IntegrityReport struct {
+	CorruptSounds []*CorruptSound `json:"corrupt_sounds"`
+	Unverified    int64           `json:"unverified"` // sounds whose checksum isn't known yet
+	Unscrubbed    int64           `json:"unscrubbed"` // sounds whose file has never been checked
+	LastScrubbed  *time.Time      `json:"last_scrubbed"`
+}
+
+// CorruptSound represents a sound whose file no longer matches its checksum, along with its owner.
+type CorruptSound struct {
+	Sound   *Sound `json:"sound"`
+	GuildID int64  `json:"guild_id"`
+	UserID  int64  `json:"user_id"`
+}
+
+// Policy represents the upload limits in effect for a guild.
+type Policy struct {
+	MaxAudioDurationMs int64    `json:"max_audio_duration_ms"`
+	MaxFilesPerUser    int      `json:"max_files_per_user"`
+	MaxUploadSize      int64    `json:"max_upload_size"`
+	MaxUserStorage     int64    `json:"max_user_storage"`  // total size of a user's sounds in the guild, in bytes
+	MaxGuildStorage    int64    `json:"max_guild_storage"` // total size of every sound in the guild, in bytes
+	AllowedTypes       []string `json:"allowed_types"`
+	RequireApproval    bool     `json:"require_approval"`
+}
+
+// MaxAudioDuration returns the maximum audio duration of the policy.
+func (p *Policy) MaxAudioDuration() time.Duration {
+	return time.Duration(p.MaxAudioDurationMs) * time.Millisecond
+}
+
+// Play represents a single playback of a sound, as reported by the bot.
+type Play struct {
+	ID        string    `json:"id" gorm:"type:text;primaryKey;not null"`
+	GuildID   int64     `json:"guild_id" gorm:"not null;index:idx_plays_guild_user"`
+	UserID    int64     `json:"user_id" gorm:"not null;index:idx_plays_guild_user"`
+	SoundID   string    `json:"sound_id" gorm:"type:text;not null;index"` // not a foreign key, history outlives the sound
+	ChannelID int64     `json:"channel_id" gorm:"not null"`
+	PlayedAt  time.Time `json:"played_at" gorm:"not null;index"`
+}
+
 // UploadRequest represents a request to upload files.
 type UploadRequest struct {
 	Files []*multipart.FileHeader `form:"files"`
@@ -49,6 +318,7 @@ type UploadResponse struct {
 	OriginalName string `json:"original_name"`
 	Size         int64  `json:"size"`
 	MimeType     string `json:"mime_type"`
+	Status       string `json:"status"`
 }
 
 // BulkUploadResponse represents a response after bulk uploading files.
@@ -69,8 +339,202 @@ type FileError struct {
 	Index    int    `json:"index"`
 }
 
+// ImportManifest represents the optional manifest of an imported archive, giving metadata for the sounds in it.
+type ImportManifest struct {
+	Sounds []*ImportManifestEntry `json:"sounds"`
+}
+
+// ImportManifestEntry represents the metadata of a single sound in an imported archive.
+type ImportManifestEntry struct {
+	File string `json:"file"` // path of the sound's file in the archive
+	UpdateSoundRequest
+}
+
+// BatchSoundsRequest represents a request to delete and reorder several of a user's sounds at once.
+type BatchSoundsRequest struct {
+	Delete []string `json:"delete"`
+	Order  []string `json:"order"` // sound IDs in their new order, sounds left out keep their position
+}
+
+// BatchItemResult represents the outcome of a single operation in a batch request.
+type BatchItemResult struct {
+	SoundID   string `json:"sound_id"`
+	Operation string `json:"operation"` // "delete" or "order"
+	Error     string `json:"error,omitempty"`
+	Index     int    `json:"index"`
+}
+
+// BatchSoundsResponse represents a response after a batch request, in the same shape as BulkUploadResponse.
+type BatchSoundsResponse struct {
+	Status           string             `json:"status"` // "success", "partial", "failure"
+	TotalItems       int                `json:"total_items"`
+	SuccessCount     int                `json:"success_count"`
+	FailureCount     int                `json:"failure_count"`
+	SuccessfulItems  []*BatchItemResult `json:"successful_items"`
+	FailedItems      []*BatchItemResult `json:"failed_items"`
+	Message          string             `json:"message"`
+	NewActiveSoundID *string            `json:"new_active_sound_id,omitempty"`
+}
+
 // UpdateSettingsRequest represents a request to update user settings.
 type UpdateSettingsRequest struct {
 	ActiveSoundID *string `json:"active_sound_id"`
 	Mode          *string `json:"mode"`
 }
+
+// CreatePlayRequest represents a request from the bot to record a playback.
+type CreatePlayRequest struct {
+	GuildID   int64      `json:"guild_id" binding:"required"`
+	UserID    int64      `json:"user_id" binding:"required"`
+	SoundID   string     `json:"sound_id" binding:"required"`
+	ChannelID int64      `json:"channel_id" binding:"required"`
+	PlayedAt  *time.Time `json:"played_at"`
+}
+
+// CopySoundRequest represents the request body for copying a sound to another guild.
+type CopySoundRequest struct {
+	GuildID int64 `json:"guild_id" binding:"required"`
+}
+
+// PlayFilter narrows down a play history query.
+type PlayFilter struct {
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// SoundPlayStat represents the number of plays of a single sound.
+type SoundPlayStat struct {
+	SoundID      string `json:"sound_id"`
+	OriginalName string `json:"original_name"`
+	DisplayName  string `json:"display_name"`
+	PlayCount    int64  `json:"play_count"`
+}
+
+// UserPlayStat represents the number of plays of a single user's sounds.
+type UserPlayStat struct {
+	UserID    int64 `json:"user_id"`
+	PlayCount int64 `json:"play_count"`
+}
+
+// UpdateGuildSettingsRequest represents a request to update guild settings.
+// Policy overrides are reset to the global default by listing them in ResetOverrides.
+type UpdateGuildSettingsRequest struct {
+	DefaultMode        *string   `json:"default_mode"`
+	RequireApproval    *bool     `json:"require_approval"`
+	Enabled            *bool     `json:"enabled"`
+	QuietHoursStart    *string   `json:"quiet_hours_start"` // an empty string clears quiet hours
+	QuietHoursEnd      *string   `json:"quiet_hours_end"`
+	Timezone           *string   `json:"timezone"`
+	DefaultSoundMode   *string   `json:"default_sound_mode"`
+	DefaultSoundID     *string   `json:"default_sound_id"` // an empty string clears the default sound
+	DefaultSoundPool   *[]string `json:"default_sound_pool"`
+	ReportThreshold    *int      `json:"report_threshold"`
+	MaxAudioDurationMs *int64    `json:"max_audio_duration_ms"`
+	MaxFilesPerUser    *int      `json:"max_files_per_user"`
+	MaxUploadSize      *int64    `json:"max_upload_size"`
+	MaxUserStorage     *int64    `json:"max_user_storage"`
+	MaxGuildStorage    *int64    `json:"max_guild_storage"`
+	AllowedTypes       *[]string `json:"allowed_types"`
+	ResetOverrides     []string  `json:"reset_overrides"` // JSON names of the policy overrides to reset
+}
+
+// IsEmpty checks whether the request does not update any field.
+func (r *UpdateGuildSettingsRequest) IsEmpty() bool {
+	return r.DefaultMode == nil &&
+		r.RequireApproval == nil &&
+		r.Enabled == nil &&
+		r.QuietHoursStart == nil &&
+		r.QuietHoursEnd == nil &&
+		r.Timezone == nil &&
+		r.DefaultSoundMode == nil &&
+		r.DefaultSoundID == nil &&
+		r.DefaultSoundPool == nil &&
+		r.ReportThreshold == nil &&
+		r.MaxAudioDurationMs == nil &&
+		r.MaxFilesPerUser == nil &&
+		r.MaxUploadSize == nil &&
+		r.MaxUserStorage == nil &&
+		r.MaxGuildStorage == nil &&
+		r.AllowedTypes == nil &&
+		r.ResetOverrides == nil
+}
+
+// ReviewSoundRequest represents a request from a guild admin to reject a sound.
+type ReviewSoundRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// BanRequest represents a request from a guild admin to ban a user from join sounds.
+type BanRequest struct {
+	Reason    *string    `json:"reason" binding:"omitempty,max=500"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ReportSoundRequest represents a request from a guild member to report a sound.
+type ReportSoundRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// UpdateSoundRequest represents the request body for updating a sound's metadata. An empty value clears the field.
+type UpdateSoundRequest struct {
+	DisplayName *string   `json:"display_name"`
+	Emoji       *string   `json:"emoji"`
+	Tags        *[]string `json:"tags"`
+	Description *string   `json:"description"`
+}
+
+// CreateShareRequest represents a request from a sound's owner to create a share token.
+type CreateShareRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+	SingleUse bool       `json:"single_use"`
+}
+
+// RedeemShareRequest represents a request to clone a shared sound into the caller's sounds in a guild.
+type RedeemShareRequest struct {
+	GuildID int64 `json:"guild_id" binding:"required"`
+}
+
+// SignedURL represents a URL to download a sound file, which stops working once it expires.
+type SignedURL struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at"` // nil if sound URLs are not signed, in which case it never expires
+}
+
+// ModerationItem represents a sound awaiting review, along with its uploader.
+type ModerationItem struct {
+	Sound  *Sound `json:"sound"`
+	UserID int64  `json:"user_id"`
+}
+
+// Resolution represents the outcome of resolving which sound to play when a user joins.
+type Resolution struct {
+	Play   bool   `json:"play"`
+	Reason string `json:"reason,omitempty"` // why nothing should be played
+	Source string `json:"source,omitempty"` // whether the sound is the user's own or the guild default
+	Sound  *Sound `json:"sound"`
+}
+
+// UserOverview represents a user's sounds and settings, as seen by a guild admin.
+type UserOverview struct {
+	User    *User    `json:"user"`
+	Setting *Setting `json:"setting"`
+	Sounds  []Sound  `json:"sounds"`
+}
+
+// ExportManifest represents everything held about a Discord user across all guilds, as included in their data export.
+type ExportManifest struct {
+	UserID     int64            `json:"user_id"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Users      []*User          `json:"users"`
+	Sounds     []*ExportedSound `json:"sounds"`
+	Settings   []*Setting       `json:"settings"`
+}
+
+// ExportedSound represents a sound in a data export, along with where its file is in the archive.
+type ExportedSound struct {
+	Sound   *Sound `json:"sound"`
+	GuildID int64  `json:"guild_id"`
+	File    string `json:"file"` // empty if the file is missing from storage
+}
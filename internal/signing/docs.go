@@ -0,0 +1,2 @@
+// Package signing provides signed, expiring URLs for downloading sound files.
+package signing
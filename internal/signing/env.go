@@ -0,0 +1,42 @@
+package signing
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// NewFromEnv creates the Signer configured by the SOUND_URL_SIGNING_KEYS environment variable, a comma-separated list
+// of id:secret pairs with the signing key first. It returns nil if no keys are set, in which case sound URLs are not
+// signed.
+func NewFromEnv() (*Signer, error) {
+	value := os.Getenv("SOUND_URL_SIGNING_KEYS")
+	if value == "" {
+		return nil, nil //nolint:nilnil // signing is disabled
+	}
+
+	var keys []Key
+
+	for pair := range strings.SplitSeq(value, ",") {
+		id, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, errors.New("SOUND_URL_SIGNING_KEYS must be a comma-separated list of id:secret pairs")
+		}
+
+		keys = append(keys, Key{ID: id, Secret: []byte(secret)})
+	}
+
+	ttl, err := utils.GetEnvDuration("SOUND_URL_TTL", utils.DefaultSignedURLTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	allowUnsigned, err := utils.GetEnvBool("ALLOW_UNSIGNED_SOUND_URLS", false)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSigner(keys, ttl, allowUnsigned)
+}
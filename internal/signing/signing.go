@@ -0,0 +1,149 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+const (
+	expiresParam   = "expires"
+	keyParam       = "key"
+	signatureParam = "signature"
+)
+
+var (
+	// ErrUnsigned is returned when verifying a URL that carries no signature while unsigned URLs are not allowed.
+	ErrUnsigned = errors.New("URL is not signed")
+	// ErrInvalidSignature is returned when verifying a URL whose signature does not match any active key.
+	ErrInvalidSignature = errors.New("invalid URL signature")
+	// ErrExpired is returned when verifying a correctly signed URL that has expired.
+	ErrExpired = errors.New("URL has expired")
+)
+
+// Key is a secret that URLs are signed with, identified by an ID that is carried in the URL so that keys can be
+// rotated.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Signer signs and verifies sound download URLs. URLs are signed with the first key and verified with any of them, so
+// a key can be rotated by putting a new one first and dropping the old one once the URLs it signed have expired.
+type Signer struct {
+	keys          []Key
+	ttl           time.Duration
+	allowUnsigned bool
+}
+
+// NewSigner creates a Signer whose URLs are valid for the given time. Unsigned URLs pass verification if
+// allowUnsigned is set, so that clients can be migrated before they are required.
+func NewSigner(keys []Key, ttl time.Duration, allowUnsigned bool) (*Signer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+
+	if ttl <= 0 {
+		return nil, errors.New("signed URLs must be valid for a positive duration")
+	}
+
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		if key.ID == "" || strings.ContainsAny(key.ID, ":,") {
+			return nil, fmt.Errorf("invalid signing key ID %q", key.ID)
+		}
+
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate signing key ID %q", key.ID)
+		}
+
+		if len(key.Secret) < utils.MinSigningKeyLength {
+			return nil, fmt.Errorf("signing key %q must be at least %d bytes long", key.ID, utils.MinSigningKeyLength)
+		}
+
+		seen[key.ID] = true
+	}
+
+	return &Signer{keys: keys, ttl: ttl, allowUnsigned: allowUnsigned}, nil
+}
+
+// SignSound returns a URL to download the sound with the given ID, valid from the given time until the signer's TTL
+// has passed.
+func (s *Signer) SignSound(soundID string, now time.Time) *models.SignedURL {
+	expiresAt := now.Add(s.ttl).Truncate(time.Second).UTC()
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	key := s.keys[0]
+
+	query := url.Values{}
+	query.Set(expiresParam, expires)
+	query.Set(keyParam, key.ID)
+	query.Set(signatureParam, sign(key, soundID, expires))
+
+	return &models.SignedURL{
+		URL:       SoundPath(soundID) + "?" + query.Encode(),
+		ExpiresAt: &expiresAt,
+	}
+}
+
+// Verify checks the signature of a URL to download the sound with the given ID, given the URL's query parameters.
+func (s *Signer) Verify(soundID string, query url.Values, now time.Time) error {
+	signature := query.Get(signatureParam)
+	if signature == "" {
+		if s.allowUnsigned {
+			return nil
+		}
+
+		return ErrUnsigned
+	}
+
+	expires := query.Get(expiresParam)
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	keyID := query.Get(keyParam)
+
+	for _, key := range s.keys {
+		if key.ID != keyID {
+			continue
+		}
+
+		if !hmac.Equal([]byte(signature), []byte(sign(key, soundID, expires))) {
+			return ErrInvalidSignature
+		}
+
+		if now.Unix() > expiresAt {
+			return ErrExpired
+		}
+
+		return nil
+	}
+
+	// the key has been rotated out, or never existed
+	return ErrInvalidSignature
+}
+
+// SoundPath returns the path that the sound with the given ID is downloaded from, without a signature.
+func SoundPath(soundID string) string {
+	return "/api/v1/sound/" + url.PathEscape(soundID)
+}
+
+// sign returns the signature of a URL to download the sound with the given ID until the given Unix time.
+func sign(key Key, soundID, expires string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	mac.Write([]byte(soundID + "\n" + expires))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,76 @@
+package signing_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/signing"
+)
+
+func TestSigner(t *testing.T) {
+	t.Parallel()
+
+	oldKey := signing.Key{ID: "old", Secret: []byte(strings.Repeat("o", 32))}
+	newKey := signing.Key{ID: "new", Secret: []byte(strings.Repeat("n", 32))}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	signer, err := signing.NewSigner([]signing.Key{oldKey}, time.Minute, false)
+	require.NoError(t, err)
+
+	signed := signer.SignSound("sound-id", now)
+	assert.Equal(t, now.Add(time.Minute), *signed.ExpiresAt)
+
+	query := parseQuery(t, signed.URL, "/api/v1/sound/sound-id")
+
+	require.NoError(t, signer.Verify("sound-id", query, now))
+	require.ErrorIs(t, signer.Verify("other-id", query, now), signing.ErrInvalidSignature)
+	require.ErrorIs(t, signer.Verify("sound-id", query, now.Add(2*time.Minute)), signing.ErrExpired)
+	require.ErrorIs(t, signer.Verify("sound-id", url.Values{}, now), signing.ErrUnsigned)
+
+	extended := url.Values{}
+	for name, values := range query {
+		extended[name] = values
+	}
+
+	extended.Set("expires", "4102444800")
+	require.ErrorIs(t, signer.Verify("sound-id", extended, now), signing.ErrInvalidSignature)
+
+	// while rotating, URLs signed with the old key keep working and new ones are signed with the new key
+	rotating, err := signing.NewSigner([]signing.Key{newKey, oldKey}, time.Minute, true)
+	require.NoError(t, err)
+	require.NoError(t, rotating.Verify("sound-id", query, now))
+	require.NoError(t, rotating.Verify("sound-id", url.Values{}, now))
+
+	rotated := parseQuery(t, rotating.SignSound("sound-id", now).URL, "/api/v1/sound/sound-id")
+	assert.Equal(t, "new", rotated.Get("key"))
+
+	// once the old key is dropped, its URLs stop working
+	rotating, err = signing.NewSigner([]signing.Key{newKey}, time.Minute, false)
+	require.NoError(t, err)
+	require.ErrorIs(t, rotating.Verify("sound-id", query, now), signing.ErrInvalidSignature)
+	require.NoError(t, rotating.Verify("sound-id", rotated, now))
+
+	_, err = signing.NewSigner([]signing.Key{{ID: "short", Secret: []byte("secret")}}, time.Minute, false)
+	require.Error(t, err)
+
+	_, err = signing.NewSigner([]signing.Key{newKey, newKey}, time.Minute, false)
+	require.Error(t, err)
+
+	_, err = signing.NewSigner(nil, time.Minute, false)
+	require.Error(t, err)
+}
+
+func parseQuery(t *testing.T, rawURL, path string) url.Values {
+	t.Helper()
+
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	assert.Equal(t, path, parsed.Path)
+
+	return parsed.Query()
+}
@@ -0,0 +1,2 @@
+// Package storage provides the backends that sound files are stored in.
+package storage
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// NewFromEnv creates the storage selected by the STORAGE_BACKEND environment variable, configured from the
+// environment.
+func NewFromEnv(ctx context.Context) (Storage, error) {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = utils.StorageBackendLocal
+	}
+
+	switch backend {
+	case utils.StorageBackendLocal:
+		soundsFilePath := os.Getenv("SOUNDS_PATH")
+		if soundsFilePath == "" {
+			soundsFilePath = "./data/sounds"
+		}
+
+		return NewLocal(soundsFilePath)
+	case utils.StorageBackendS3:
+		useSSL, err := utils.GetEnvBool("S3_USE_SSL", true)
+		if err != nil {
+			return nil, err
+		}
+
+		region := os.Getenv("S3_REGION")
+		if region == "" {
+			region = utils.DefaultS3Region
+		}
+
+		return NewS3(ctx, &S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			Region:          region,
+			Prefix:          os.Getenv("S3_PREFIX"),
+			UseSSL:          useSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// Local stores files in a directory of the local filesystem.
+type Local struct {
+	dir string
+}
+
+// NewLocal creates a Local storage in the given directory, creating the directory if it doesn't exist. Temporary files
+// left behind by writes that were cut short are removed once they are older than utils.UploadGracePeriod, as younger
+// ones may belong to a write still in progress in another process.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	temps, err := filepath.Glob(filepath.Join(dir, tempPattern))
+	if err != nil {
+		return nil, err
+	}
+
+	staleBefore := time.Now().Add(-utils.UploadGracePeriod)
+
+	for _, temp := range temps {
+		info, err := os.Stat(temp)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check temporary file: %w", err)
+		}
+
+		if info.ModTime().After(staleBefore) {
+			continue
+		}
+
+		if err := os.Remove(temp); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove temporary file: %w", err)
+		}
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+// tempPattern is the pattern of the names of files being written, which start with a dot so that they can never clash
+// with a stored file.
+const tempPattern = ".tmp-*"
+
+// Put implements Storage. The file is written under a temporary name, synced and renamed into place, so that a crash
+// never leaves a partial file under the given name.
+func (l *Local) Put(_ context.Context, name string, r io.Reader, _ int64) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(l.dir, tempPattern)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+
+	if err := writeFile(file, r); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		_ = os.Remove(file.Name())
+		return fmt.Errorf("cannot rename file: %w", err)
+	}
+
+	return l.syncDir()
+}
+
+// writeFile copies r into the file, syncs it to disk and closes it.
+func writeFile(file *os.File, r io.Reader) error {
+	if _, err := io.Copy(file, r); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot write file: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot sync file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("cannot close file: %w", err)
+	}
+
+	return nil
+}
+
+// syncDir syncs the directory to disk, making the creation or removal of the files in it durable.
+func (l *Local) syncDir() error {
+	dir, err := os.Open(l.dir)
+	if err != nil {
+		return fmt.Errorf("cannot open storage directory: %w", err)
+	}
+
+	defer func() {
+		_ = dir.Close()
+	}()
+
+	if err := dir.Sync(); err != nil {
+		return fmt.Errorf("cannot sync storage directory: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements Storage.
+func (l *Local) Get(_ context.Context, name string) (File, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path) //nolint:gosec // path is checked to be within the directory
+}
+
+// Stat implements Storage.
+func (l *Local) Stat(_ context.Context, name string) (fs.FileInfo, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Stat(path)
+}
+
+// Delete implements Storage.
+func (l *Local) Delete(_ context.Context, name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List implements Storage.
+func (l *Local) List(_ context.Context) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			continue // removed since the directory was read
+		} else if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Copy implements Storage, sharing the stored bytes through a hard link where the filesystem supports it.
+func (l *Local) Copy(ctx context.Context, src, dst string) error {
+	srcPath, err := l.path(src)
+	if err != nil {
+		return err
+	}
+
+	dstPath, err := l.path(dst)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Link(srcPath, dstPath); err == nil {
+		return l.syncDir()
+	}
+
+	in, err := l.Get(ctx, src)
+	if err != nil {
+		return fmt.Errorf("cannot open source file: %w", err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("cannot stat source file: %w", err)
+	}
+
+	return l.Put(ctx, dst, in, info.Size())
+}
+
+// path returns the path of the file stored under name, which must not lead outside the directory.
+func (l *Local) path(name string) (string, error) {
+	if !filepath.IsLocal(name) || filepath.Base(name) != name || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid file name: %s", name)
+	}
+
+	return filepath.Join(l.dir, name), nil
+}
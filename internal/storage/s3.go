@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3 storage.
+type S3Config struct {
+	Endpoint        string // host and optional port, without a scheme
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+	Prefix          string // prepended to the name of every file, e.g. "sounds/"
+	UseSSL          bool
+	Transport       http.RoundTripper // optional, e.g. to trust a test server's certificate
+}
+
+// S3 stores files in a bucket of an S3-compatible object store.
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 creates an S3 storage for an existing bucket.
+func NewS3(ctx context.Context, config *S3Config) (*S3, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("an S3 endpoint and bucket are required")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, ""),
+		Secure:    config.UseSSL,
+		Region:    config.Region,
+		Transport: config.Transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	exists, err := client.BucketExists(ctx, config.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check S3 bucket: %w", err)
+	}
+
+	if !exists {
+		return nil, fmt.Errorf("S3 bucket %s does not exist", config.Bucket)
+	}
+
+	return &S3{client: client, bucket: config.Bucket, prefix: config.Prefix}, nil
+}
+
+// Put implements Storage.
+func (s *S3) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.prefix+name, r, size, minio.PutObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot upload object: %w", err)
+	}
+
+	return nil
+}
+
+// Get implements Storage.
+func (s *S3) Get(ctx context.Context, name string) (File, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.prefix+name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	// the object is only fetched once it is first used, so make sure it exists now
+	info, err := object.Stat()
+	if err != nil {
+		_ = object.Close()
+		return nil, mapError(err)
+	}
+
+	return &s3Object{Object: object, info: s.fileInfo(&info)}, nil
+}
+
+// Stat implements Storage.
+func (s *S3) Stat(ctx context.Context, name string) (fs.FileInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, s.prefix+name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return s.fileInfo(&info), nil
+}
+
+// Delete implements Storage.
+func (s *S3) Delete(ctx context.Context, name string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, s.prefix+name, minio.RemoveObjectOptions{})
+	if err != nil && !errors.Is(mapError(err), fs.ErrNotExist) {
+		return fmt.Errorf("cannot remove object: %w", err)
+	}
+
+	return nil
+}
+
+// List implements Storage.
+func (s *S3) List(ctx context.Context) ([]fs.FileInfo, error) {
+	infos := make([]fs.FileInfo, 0)
+
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("cannot list objects: %w", object.Err)
+		}
+
+		infos = append(infos, s.fileInfo(&object))
+	}
+
+	return infos, nil
+}
+
+// Copy implements Storage, copying the object within the bucket.
+func (s *S3) Copy(ctx context.Context, src, dst string) error {
+	_, err := s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucket, Object: s.prefix + dst},
+		minio.CopySrcOptions{Bucket: s.bucket, Object: s.prefix + src},
+	)
+	if err != nil {
+		return fmt.Errorf("cannot copy object: %w", mapError(err))
+	}
+
+	return nil
+}
+
+func (s *S3) fileInfo(info *minio.ObjectInfo) *fileInfo {
+	return &fileInfo{
+		name:    strings.TrimPrefix(info.Key, s.prefix),
+		size:    info.Size,
+		modTime: info.LastModified,
+	}
+}
+
+// s3Object is an object opened for reading.
+type s3Object struct {
+	*minio.Object
+	info *fileInfo
+}
+
+// Stat implements File.
+func (o *s3Object) Stat() (fs.FileInfo, error) {
+	return o.info, nil
+}
+
+// mapError wraps errors about missing objects so that they match fs.ErrNotExist.
+func mapError(err error) error {
+	var response minio.ErrorResponse
+	if errors.As(err, &response) && (response.Code == "NoSuchKey" || response.StatusCode == http.StatusNotFound) {
+		return fmt.Errorf("%w: %w", fs.ErrNotExist, err)
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// File is a stored file opened for reading.
+type File interface {
+	io.ReadSeekCloser
+	Stat() (fs.FileInfo, error)
+}
+
+// Storage stores the files of sounds by name. Names are the internal filenames of sounds and their versions. Missing
+// files are reported with errors that match fs.ErrNotExist.
+type Storage interface {
+	// Put stores the content of r under name, replacing any file with that name. size must be the length of the
+	// content. The file only appears once it is complete and durable, even if the process crashes part way.
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	// Get opens the file stored under name.
+	Get(ctx context.Context, name string) (File, error)
+	// Stat describes the file stored under name.
+	Stat(ctx context.Context, name string) (fs.FileInfo, error)
+	// Delete removes the file stored under name. Removing a file that doesn't exist is not an error.
+	Delete(ctx context.Context, name string) error
+	// List describes every stored file.
+	List(ctx context.Context) ([]fs.FileInfo, error)
+	// Copy stores the file stored under src under dst as well, sharing the stored bytes where the backend allows. Like
+	// Put, it never leaves a partial file under dst.
+	Copy(ctx context.Context, src, dst string) error
+}
+
+// Checksum reads the file stored under name to the end and returns its checksum, see utils.Checksum.
+func Checksum(ctx context.Context, store Storage, name string) (string, error) {
+	file, err := store.Get(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", name, err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	checksum, err := utils.Checksum(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	return checksum, nil
+}
+
+// fileInfo describes a stored file for backends that don't have an fs.FileInfo of their own.
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() fs.FileMode  { return 0o444 }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return false }
+func (fi *fileInfo) Sys() any           { return nil }
@@ -0,0 +1,384 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/storage"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestLocal(t *testing.T) {
+	t.Parallel()
+
+	store, err := storage.NewLocal(filepath.Join(t.TempDir(), "sounds"))
+	require.NoError(t, err)
+
+	testStorage(t, store)
+
+	assert.Error(t, store.Put(t.Context(), "../escape.mp3", strings.NewReader("sound"), 5))
+}
+
+func TestLocalCopy(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	store, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(t.Context(), "source.mp3", strings.NewReader("sound"), 5))
+	require.NoError(t, store.Copy(t.Context(), "source.mp3", "copy.mp3"))
+
+	// removing the source must leave the copy intact, as purging a sound removes its file
+	require.NoError(t, store.Delete(t.Context(), "source.mp3"))
+
+	content, err := os.ReadFile(filepath.Join(dir, "copy.mp3")) //nolint:gosec // test file
+	require.NoError(t, err)
+	assert.Equal(t, "sound", string(content))
+
+	assert.Error(t, store.Copy(t.Context(), "source.mp3", "missing.mp3"))
+}
+
+func TestLocalInterruptedPut(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// a temporary file left behind by a crash, and one being written by another process
+	stale := time.Now().Add(-2 * utils.UploadGracePeriod)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tmp-123"), []byte("so"), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, ".tmp-123"), stale, stale))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tmp-456"), []byte("so"), 0o600))
+
+	store, err := storage.NewLocal(dir)
+	require.NoError(t, err)
+
+	reader := io.MultiReader(strings.NewReader("so"), iotest.ErrReader(errors.New("connection reset")))
+	require.Error(t, store.Put(t.Context(), "sound.mp3", reader, 5))
+
+	_, err = store.Stat(t.Context(), "sound.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ".tmp-456", entries[0].Name())
+}
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(newFakeS3("sounds"))
+	t.Cleanup(server.Close)
+
+	endpoint, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	config := &storage.S3Config{
+		Endpoint:        endpoint.Host,
+		Bucket:          "sounds",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Region:          "us-east-1",
+		Prefix:          "join-sounds/",
+	}
+
+	store, err := storage.NewS3(t.Context(), config)
+	require.NoError(t, err)
+
+	testStorage(t, store)
+
+	config.Bucket = "missing"
+	_, err = storage.NewS3(t.Context(), config)
+	assert.Error(t, err)
+}
+
+// testStorage checks the behaviour that every Storage implementation must share.
+func testStorage(t *testing.T, store storage.Storage) {
+	t.Helper()
+
+	ctx := t.Context()
+
+	require.NoError(t, store.Put(ctx, "a.mp3", strings.NewReader("first"), 5))
+	require.NoError(t, store.Put(ctx, "a.mp3", strings.NewReader("replaced"), 8))
+	require.NoError(t, store.Put(ctx, "b.wav", bytes.NewReader([]byte("second")), 6))
+
+	file, err := store.Get(ctx, "a.mp3")
+	require.NoError(t, err)
+
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, "replaced", string(content))
+
+	// files can be read again, e.g. to validate them and then serve them
+	_, err = file.Seek(2, io.SeekStart)
+	require.NoError(t, err)
+
+	content, err = io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, "placed", string(content))
+
+	info, err := file.Stat()
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), info.Size())
+	require.NoError(t, file.Close())
+
+	info, err = store.Stat(ctx, "b.wav")
+	require.NoError(t, err)
+	assert.Equal(t, "b.wav", info.Name())
+	assert.Equal(t, int64(6), info.Size())
+	assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+
+	require.NoError(t, store.Copy(ctx, "b.wav", "c.wav"))
+
+	files, err := store.List(ctx)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	assert.ElementsMatch(t, []string{"a.mp3", "b.wav", "c.wav"}, names)
+
+	require.NoError(t, store.Delete(ctx, "a.mp3"))
+	require.NoError(t, store.Delete(ctx, "a.mp3"))
+
+	_, err = store.Get(ctx, "a.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = store.Stat(ctx, "a.mp3")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	require.ErrorIs(t, store.Copy(ctx, "a.mp3", "d.mp3"), fs.ErrNotExist)
+}
+
+// fakeS3 is an in-process stand-in for an S3-compatible object store, implementing just the part of the API that the
+// S3 storage uses, without checking signatures.
+type fakeS3 struct {
+	bucket  string
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+}
+
+type fakeObject struct {
+	content []byte
+	modTime time.Time
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string]*fakeObject)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL.Query().Get("prefix"))
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		f.copy(w, r, key)
+	case r.Method == http.MethodPut:
+		f.put(w, r, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		object, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, key, object.modTime, bytes.NewReader(object.content))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func (f *fakeS3) put(w http.ResponseWriter, r *http.Request, key string) {
+	var body io.Reader = r.Body
+
+	// unencrypted uploads are signed chunk by chunk
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		body = newChunkedReader(r.Body)
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	f.objects[key] = &fakeObject{content: content, modTime: time.Now().UTC().Truncate(time.Second)}
+
+	w.Header().Set("ETag", `"etag"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *fakeS3) copy(w http.ResponseWriter, r *http.Request, key string) {
+	source, err := url.PathUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeS3Error(w, r, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+
+	object, ok := f.objects[strings.TrimPrefix(strings.TrimPrefix(source, "/"), f.bucket+"/")]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+		return
+	}
+
+	f.objects[key] = &fakeObject{content: object.content, modTime: time.Now().UTC().Truncate(time.Second)}
+
+	writeXML(w, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string
+		LastModified string
+	}{ETag: `"etag"`, LastModified: f.objects[key].modTime.Format(time.RFC3339)})
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{Name: f.bucket, Prefix: prefix, MaxKeys: 1000}
+
+	for key, object := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{
+				Key:          key,
+				LastModified: object.modTime.Format(time.RFC3339),
+				ETag:         `"etag"`,
+				Size:         len(object.content),
+				StorageClass: "STANDARD",
+			})
+		}
+	}
+
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+
+	writeXML(w, result)
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
+
+func writeXML(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(value)
+}
+
+// chunkedReader decodes a body uploaded with aws-chunked encoding, ignoring the chunk signatures.
+type chunkedReader struct {
+	r         *bufio.Reader
+	remaining int64
+	done      bool
+}
+
+func newChunkedReader(r io.Reader) *chunkedReader {
+	return &chunkedReader{r: bufio.NewReader(r)}
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return 0, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue // the line break after the previous chunk
+		}
+
+		sizeHex, _, _ := strings.Cut(line, ";")
+
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid chunk size %q: %w", sizeHex, err)
+		}
+
+		if size == 0 {
+			c.done = true
+			return 0, io.EOF
+		}
+
+		c.remaining = size
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+
+	if errors.Is(err, io.EOF) && c.remaining > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+
+	return n, err
+}
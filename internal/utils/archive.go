@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// ReadImportManifest reads the manifest of an imported archive, if it has one, and returns the metadata in it by the
+// path of the file it applies to.
+func ReadImportManifest(archive *zip.Reader) (map[string]*models.UpdateSoundRequest, error) {
+	metadata := make(map[string]*models.UpdateSoundRequest)
+
+	file, err := archive.Open(ImportManifestName)
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot open manifest: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	content, err := io.ReadAll(io.LimitReader(file, MaxImportManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read manifest: %w", err)
+	}
+
+	if len(content) > MaxImportManifestSize {
+		return nil, fmt.Errorf("manifest too large (max %d bytes)", MaxImportManifestSize)
+	}
+
+	var manifest models.ImportManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	for _, entry := range manifest.Sounds {
+		if entry == nil {
+			continue
+		}
+
+		metadata[path.Clean(entry.File)] = &entry.UpdateSoundRequest
+	}
+
+	return metadata, nil
+}
+
+// ExtractArchiveEntry writes the decompressed content of an archive entry to dst, and returns its size. It stops at
+// maxSize bytes whatever size the entry claims to have, so that a zip bomb can't fill the disk.
+func ExtractArchiveEntry(file *zip.File, dst io.Writer, maxSize int64) (int64, error) {
+	if file.UncompressedSize64 > uint64(maxSize) { //nolint:gosec // sizes are never negative
+		return 0, fmt.Errorf("file too large: %s (max %d bytes)", file.Name, maxSize)
+	}
+
+	in, err := file.Open()
+	if err != nil {
+		return 0, fmt.Errorf("cannot open archive entry: %w", err)
+	}
+
+	defer func() {
+		_ = in.Close()
+	}()
+
+	written, err := io.Copy(dst, io.LimitReader(in, maxSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("cannot extract archive entry: %w", err)
+	}
+
+	if written > maxSize {
+		return 0, fmt.Errorf("file too large: %s (max %d bytes)", file.Name, maxSize)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,130 @@
+package utils_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"hash/crc32"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+// newArchive builds an in-memory ZIP archive with the given files.
+func newArchive(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	writer := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, writer.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	return reader
+}
+
+func TestReadImportManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  bool
+		want     []string
+	}{
+		{name: "No manifest"},
+		{
+			name:     "Manifest",
+			manifest: `{"sounds": [{"file": "pack/./airhorn.mp3", "display_name": "Airhorn", "tags": ["loud"]}, null]}`,
+			want:     []string{"pack/airhorn.mp3"},
+		},
+		{name: "Invalid manifest", manifest: `{"sounds": {}}`, wantErr: true},
+		{name: "Manifest too large", manifest: strings.Repeat(" ", utils.MaxImportManifestSize+1) + "{}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := map[string]string{"pack/airhorn.mp3": "sound"}
+			if tt.manifest != "" {
+				files[utils.ImportManifestName] = tt.manifest
+			}
+
+			metadata, err := utils.ReadImportManifest(newArchive(t, files))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			assert.Len(t, metadata, len(tt.want))
+
+			for _, file := range tt.want {
+				assert.Contains(t, metadata, file)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveEntry(t *testing.T) {
+	t.Parallel()
+
+	archive := newArchive(t, map[string]string{"small.mp3": "sound", "large.mp3": strings.Repeat("a", 100)})
+
+	for _, file := range archive.File {
+		var buf bytes.Buffer
+
+		size, err := utils.ExtractArchiveEntry(file, &buf, 10)
+		if file.Name == "large.mp3" {
+			assert.Error(t, err)
+		} else {
+			require.NoError(t, err)
+			assert.Equal(t, int64(5), size)
+			assert.Equal(t, "sound", buf.String())
+		}
+	}
+
+	// an entry can claim to be smaller than it is
+	var buf bytes.Buffer
+
+	writer := zip.NewWriter(&buf)
+	content := []byte(strings.Repeat("a", 100))
+
+	file, err := writer.CreateRaw(&zip.FileHeader{
+		Name:               "bomb.mp3",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE(content),
+		CompressedSize64:   uint64(len(content)),
+		UncompressedSize64: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = file.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	bomb, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var extracted bytes.Buffer
+
+	_, err = utils.ExtractArchiveEntry(bomb.File[0], &extracted, 10)
+	assert.Error(t, err)
+	assert.LessOrEqual(t, extracted.Len(), 11)
+}
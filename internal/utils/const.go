@@ -2,28 +2,250 @@ package utils
 
 import "time"
 
-// MaxAudioDuration is the maximum duration for audio files that we permit users to upload.
-const MaxAudioDuration = 5 * time.Second
+const (
+	// DefaultMaxAudioDuration is the maximum duration for audio files that we permit users to upload, unless
+	// overridden by the guild.
+	DefaultMaxAudioDuration = 5 * time.Second
+	// MaxAudioDurationLimit is the highest maximum audio duration a guild can configure.
+	MaxAudioDurationLimit = 30 * time.Second
+)
 
 const (
 	// MaxPayloadSize is the maximum size for request payloads.
 	MaxPayloadSize = 50 << 20 // 50 MB
-	// MaxUploadSize is the maximum size for individual file uploads.
-	MaxUploadSize = 10 * 1024 * 1024 // 10 MB
+	// DefaultMaxUploadSize is the maximum size for individual file uploads, unless overridden by the guild.
+	DefaultMaxUploadSize = 10 * 1024 * 1024 // 10 MB
 )
 
 const (
-	// MaxFilesPerUser is the maximum number of files a user can upload.
-	MaxFilesPerUser = 5
+	// DefaultMaxFilesPerUser is the maximum number of files a user can upload, unless overridden by the guild.
+	DefaultMaxFilesPerUser = 5
+	// MaxFilesPerUserLimit is the highest maximum number of files per user a guild can configure.
+	MaxFilesPerUserLimit = 50
 	// MaxFilenameLen is the maximum length of uploaded file names.
 	MaxFilenameLen = 255
 )
 
-// AllowedTypes is a map of allowed audio file types and their corresponding extensions.
-var AllowedTypes = map[string]string{
+const (
+	// DefaultMaxUserStorage is the maximum total size of a user's sounds in a guild, unless overridden by the guild.
+	DefaultMaxUserStorage = 25 << 20 // 25 MB
+	// MaxUserStorageLimit is the highest maximum user storage a guild can configure.
+	MaxUserStorageLimit = 500 << 20 // 500 MB
+	// DefaultMaxGuildStorage is the maximum total size of every sound in a guild, unless overridden by the guild.
+	DefaultMaxGuildStorage = 1 << 30 // 1 GB
+	// MaxGuildStorageLimit is the highest maximum guild storage a guild can configure.
+	MaxGuildStorageLimit = 5 << 30 // 5 GB
+	// NoStorageLimit disables a storage quota.
+	NoStorageLimit = -1
+)
+
+// SupportedTypes is a map of the audio file types we are able to decode and their corresponding extensions.
+// Guilds may restrict uploads to a subset of these.
+var SupportedTypes = map[string]string{
 	"audio/mpeg":  ".mp3",
 	"audio/x-wav": ".wav",
 }
 
 // AllowedModes is a list of allowed playback modes.
 var AllowedModes = []string{"single", "random"}
+
+const (
+	// DefaultGuildMode is the playback mode of guilds that haven't configured one, for both users and default sounds.
+	DefaultGuildMode = "single"
+	// DefaultGuildTimezone is the timezone of the quiet hours of guilds that haven't configured one.
+	DefaultGuildTimezone = "UTC"
+)
+
+// PolicyOverrides lists the guild settings that override the global upload policy, by their JSON names.
+var PolicyOverrides = []string{
+	"max_audio_duration_ms",
+	"max_files_per_user",
+	"max_upload_size",
+	"max_user_storage",
+	"max_guild_storage",
+	"allowed_types",
+}
+
+const (
+	// SoundStatusPending is the status of a sound awaiting review by a guild admin.
+	SoundStatusPending = "pending"
+	// SoundStatusApproved is the status of a sound that can be played.
+	SoundStatusApproved = "approved"
+	// SoundStatusRejected is the status of a sound rejected by a guild admin.
+	SoundStatusRejected = "rejected"
+)
+
+const (
+	// ReportStatusOpen is the status of a report awaiting a guild admin's decision.
+	ReportStatusOpen = "open"
+	// ReportStatusResolved is the status of a report upheld by a guild admin, keeping the sound hidden.
+	ReportStatusResolved = "resolved"
+	// ReportStatusDismissed is the status of a report dismissed by a guild admin.
+	ReportStatusDismissed = "dismissed"
+)
+
+const (
+	// MaxDisplayNameLen is the maximum length of a sound's display name, in characters.
+	MaxDisplayNameLen = 50
+	// MaxDescriptionLen is the maximum length of a sound's description, in characters.
+	MaxDescriptionLen = 500
+	// MaxTags is the maximum number of tags on a sound.
+	MaxTags = 10
+	// MaxTagLen is the maximum length of a single tag, in characters.
+	MaxTagLen = 25
+	// MaxEmojiLen is the maximum length of a sound's emoji in bytes, enough for long emoji sequences and Discord
+	// custom emoji.
+	MaxEmojiLen = 64
+)
+
+const (
+	// DefaultVersionRetention is how long previous versions of a sound are kept after being replaced, unless
+	// configured otherwise.
+	DefaultVersionRetention = 30 * 24 * time.Hour
+	// DefaultTrashRetention is how long deleted sounds are kept in the trash before being purged, unless configured
+	// otherwise.
+	DefaultTrashRetention = 7 * 24 * time.Hour
+	// PurgeInterval is how often background jobs purge expired data.
+	PurgeInterval = time.Hour
+)
+
+const (
+	// UploadGracePeriod is how long an upload has to write the record of its sound after its file was scheduled for
+	// removal, before the file is removed as left behind.
+	UploadGracePeriod = time.Hour
+	// FileRemovalInterval is how often the background job removes the files scheduled for removal.
+	FileRemovalInterval = 5 * time.Minute
+	// FileRemovalRetryDelay is how long to wait before retrying a failed file removal.
+	FileRemovalRetryDelay = 15 * time.Minute
+)
+
+// MaxSearchQueryLen is the maximum length of a sound search query, in characters.
+const MaxSearchQueryLen = 100
+
+// MaxBatchSize is the maximum number of operations in a single batch request.
+const MaxBatchSize = 100
+
+const (
+	// MaxImportEntries is the maximum number of entries in an imported archive, directories included.
+	MaxImportEntries = 100
+	// MaxImportManifestSize is the maximum size of the manifest of an imported archive, once decompressed.
+	MaxImportManifestSize = 1 << 20 // 1 MB
+	// ImportManifestName is the name of the optional manifest at the root of an imported archive.
+	ImportManifestName = "manifest.json"
+)
+
+const (
+	// DefaultReportThreshold is the report threshold of guilds that haven't configured one.
+	DefaultReportThreshold = 3
+	// MaxReportThreshold is the highest report threshold a guild can configure.
+	MaxReportThreshold = 100
+)
+
+const (
+	// ResolutionReasonNoSound is returned by the playback resolution when the user has no playable sound.
+	ResolutionReasonNoSound = "no_sound"
+	// ResolutionReasonGuildDisabled is returned by the playback resolution when the guild has turned off join sounds.
+	ResolutionReasonGuildDisabled = "guild_disabled"
+	// ResolutionReasonQuietHours is returned by the playback resolution during the guild's quiet hours.
+	ResolutionReasonQuietHours = "quiet_hours"
+	// ResolutionReasonBanned is returned by the playback resolution when the user is banned in the guild.
+	ResolutionReasonBanned = "banned"
+)
+
+const (
+	// SoundSourceUser indicates that the effective sound was picked from the user's own sounds.
+	SoundSourceUser = "user"
+	// SoundSourceGuildDefault indicates that the effective sound is the guild default.
+	SoundSourceGuildDefault = "guild_default"
+	// SoundSourceNone indicates that there is no effective sound.
+	SoundSourceNone = "none"
+)
+
+// GlobalGuildID is the guild ID of the placeholder guild that holds each user's global sounds and default settings,
+// which apply in every guild where the user hasn't overridden them. Discord never assigns it to a real guild.
+const GlobalGuildID = 0
+
+const (
+	// SoundScopeUser is the scope of a user's own sounds in a guild.
+	SoundScopeUser = "user"
+	// SoundScopeLibrary is the scope of sounds in a guild's library.
+	SoundScopeLibrary = "library"
+	// SoundScopeGlobal is the scope of a user's global sounds, available in every guild.
+	SoundScopeGlobal = "global"
+)
+
+const (
+	// SettingSourceGuild means the user's settings were set in the guild itself.
+	SettingSourceGuild = "guild"
+	// SettingSourceGlobal means the user's global settings apply, as they haven't set any in the guild.
+	SettingSourceGlobal = "global"
+	// SettingSourceDefault means the user has no settings, so the guild's defaults apply.
+	SettingSourceDefault = "default"
+)
+
+// LibraryUserID is the user ID of the placeholder user that owns a guild's library sounds. Discord never assigns it
+// to a real user.
+const LibraryUserID = 0
+
+// MaxLibrarySounds is the maximum number of sounds in a guild's library.
+const MaxLibrarySounds = 50
+
+// MaxDefaultPoolSize is the maximum number of sounds in a guild's default pool.
+const MaxDefaultPoolSize = 25
+
+// QuietHoursLayout is the time layout of quiet hours boundaries.
+const QuietHoursLayout = "15:04"
+
+const (
+	// DefaultPageSize is the number of items returned by paginated endpoints when no limit is given.
+	DefaultPageSize = 25
+	// MaxPageSize is the maximum number of items a paginated endpoint will return at once.
+	MaxPageSize = 100
+)
+
+const (
+	// BotScope is the token scope granted to the Discord bot for bot-facing endpoints.
+	BotScope = "bot"
+	// AdminScope is the token scope granted to operators of the service, for endpoints that act across all guilds.
+	AdminScope = "admin"
+)
+
+const (
+	// OrphanActionDelete deletes orphaned files when reconciling storage.
+	OrphanActionDelete = "delete"
+	// OrphanActionQuarantine moves orphaned files aside when reconciling storage, renaming them with QuarantinePrefix.
+	OrphanActionQuarantine = "quarantine"
+	// QuarantinePrefix is prepended to the names of quarantined files. Internal filenames never contain a dot before
+	// their extension, so a quarantined file can't clash with a sound's.
+	QuarantinePrefix = "quarantine."
+	// DefaultOrphanGracePeriod is how old an unreferenced file must be before it is treated as orphaned, unless
+	// configured otherwise.
+	DefaultOrphanGracePeriod = 24 * time.Hour
+	// DefaultReconcileInterval is how often storage is reconciled in the background, unless configured otherwise.
+	DefaultReconcileInterval = 24 * time.Hour
+	// DefaultScrubInterval is how often a batch of stored files is checked against their checksums in the background,
+	// unless configured otherwise.
+	DefaultScrubInterval = time.Hour
+	// DefaultScrubBatchSize is how many stored files are checked against their checksums each time, unless configured
+	// otherwise.
+	DefaultScrubBatchSize = 100
+)
+
+const (
+	// StorageBackendLocal stores sound files in a directory on the local filesystem.
+	StorageBackendLocal = "local"
+	// StorageBackendS3 stores sound files in an S3-compatible object store.
+	StorageBackendS3 = "s3"
+	// DefaultS3Region is the region used for the S3-compatible object store, unless configured otherwise.
+	DefaultS3Region = "us-east-1"
+)
+
+const (
+	// DefaultSignedURLTTL is how long signed sound URLs are valid for, unless configured otherwise.
+	DefaultSignedURLTTL = 15 * time.Minute
+	// MinSigningKeyLength is the minimum length in bytes of the secrets that sound URLs are signed with.
+	MinSigningKeyLength = 32
+)
+
+// DeletionRequestedBySelf marks a user deletion that was requested by the user themselves.
+const DeletionRequestedBySelf = "self"
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnvDuration reads a duration such as "720h" from the environment, falling back to the given default when the
+// variable is not set.
+func GetEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration such as 720h", key)
+	}
+
+	return duration, nil
+}
+
+// GetEnvBool reads a boolean such as "true" from the environment, falling back to the given default when the variable
+// is not set.
+func GetEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", key)
+	}
+
+	return parsed, nil
+}
+
+// GetEnvInt reads a positive integer from the environment, falling back to the given default when the variable is not
+// set.
+func GetEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+
+	return parsed, nil
+}
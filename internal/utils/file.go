@@ -1,11 +1,14 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"maps"
 	"mime/multipart"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -19,9 +22,9 @@ import (
 	"github.com/mocbotau/api-join-sound/internal/models"
 )
 
-// ValidateFileUpload checks if the uploaded file meets the required criteria.
-func ValidateFileUpload(fileHeader *multipart.FileHeader) (string, error) {
-	filename, err := validateFileMetadata(fileHeader)
+// ValidateFileUpload checks if the uploaded file meets the criteria of the given policy.
+func ValidateFileUpload(fileHeader *multipart.FileHeader, policy *models.Policy) (string, error) {
+	filename, err := validateFileMetadata(fileHeader.Filename, fileHeader.Size, policy)
 	if err != nil {
 		return "", err
 	}
@@ -35,12 +38,28 @@ func ValidateFileUpload(fileHeader *multipart.FileHeader) (string, error) {
 		_ = file.Close()
 	}()
 
-	kind, err := detectFileType(file, filename)
+	return validateAudio(file, filename, policy)
+}
+
+// ValidateStoredFile checks if a stored sound file meets the criteria of the given policy, e.g. before copying it to
+// another guild.
+func ValidateStoredFile(file io.ReadSeekCloser, size int64, originalName string, policy *models.Policy) (string, error) {
+	filename, err := validateFileMetadata(originalName, size, policy)
+	if err != nil {
+		return "", err
+	}
+
+	return validateAudio(file, filename, policy)
+}
+
+// validateAudio checks the content of the file, returning its MIME type.
+func validateAudio(file io.ReadSeekCloser, filename string, policy *models.Policy) (string, error) {
+	kind, err := detectFileType(file, filename, policy)
 	if err != nil {
 		return "", err
 	}
 
-	if err := checkAudioDuration(file, kind); err != nil {
+	if err := checkAudioDuration(file, kind, policy); err != nil {
 		return "", err
 	}
 
@@ -48,20 +67,20 @@ func ValidateFileUpload(fileHeader *multipart.FileHeader) (string, error) {
 }
 
 // validateFileMetadata validates basic file metadata, and sanities the file path.
-func validateFileMetadata(fileHeader *multipart.FileHeader) (string, error) {
-	if fileHeader.Size > MaxUploadSize {
-		return "", fmt.Errorf("file too large: %s (max %d bytes)", fileHeader.Filename, MaxUploadSize)
+func validateFileMetadata(name string, size int64, policy *models.Policy) (string, error) {
+	if size > policy.MaxUploadSize {
+		return "", fmt.Errorf("file too large: %s (max %d bytes)", name, policy.MaxUploadSize)
 	}
 
-	if len(fileHeader.Filename) > MaxFilenameLen {
-		return "", fmt.Errorf("filename too long: %s (max %d characters)", fileHeader.Filename, MaxFilenameLen)
+	if len(name) > MaxFilenameLen {
+		return "", fmt.Errorf("filename too long: %s (max %d characters)", name, MaxFilenameLen)
 	}
 
-	if fileHeader.Size == 0 {
-		return "", fmt.Errorf("empty file: %s", fileHeader.Filename)
+	if size == 0 {
+		return "", fmt.Errorf("empty file: %s", name)
 	}
 
-	filename := strings.TrimSpace(fileHeader.Filename)
+	filename := strings.TrimSpace(name)
 	if filename == "" {
 		return "", fmt.Errorf("filename cannot be empty")
 	}
@@ -71,7 +90,7 @@ func validateFileMetadata(fileHeader *multipart.FileHeader) (string, error) {
 }
 
 // detectFileType determines the MIME type of the uploaded file by reading the actual data.
-func detectFileType(file multipart.File, filename string) (string, error) {
+func detectFileType(file io.Reader, filename string, policy *models.Policy) (string, error) {
 	buffer := make([]byte, 8192)
 
 	n, err := file.Read(buffer)
@@ -90,12 +109,13 @@ func detectFileType(file multipart.File, filename string) (string, error) {
 		return "", fmt.Errorf("unknown or unsupported file type")
 	}
 
-	if !slices.Contains(slices.Collect(maps.Keys(AllowedTypes)), kind.MIME.Value) {
-		return "", fmt.Errorf("unsupported file type: %s (detected: %s)", filepath.Ext(filename), kind.MIME.Value)
+	if !slices.Contains(policy.AllowedTypes, kind.MIME.Value) {
+		return "", fmt.Errorf("unsupported file type: %s (detected: %s, allowed: %s)",
+			filepath.Ext(filename), kind.MIME.Value, strings.Join(policy.AllowedTypes, ", "))
 	}
 
 	ext := strings.ToLower(filepath.Ext(filename))
-	if expected, ok := AllowedTypes[kind.MIME.Value]; !ok || expected != ext {
+	if expected, ok := SupportedTypes[kind.MIME.Value]; !ok || expected != ext {
 		return "", fmt.Errorf("file type mismatch: %s (expected: %s, detected: %s)", ext, expected, kind.MIME.Value)
 	}
 
@@ -103,7 +123,7 @@ func detectFileType(file multipart.File, filename string) (string, error) {
 }
 
 // checkAudioDuration will restrict the audio duration to a maximum limit.
-func checkAudioDuration(file multipart.File, kind string) error {
+func checkAudioDuration(file io.ReadSeekCloser, kind string, policy *models.Policy) error {
 	if _, err := file.Seek(0, io.SeekStart); err != nil {
 		return fmt.Errorf("cannot rewind file for duration check: %w", err)
 	}
@@ -132,8 +152,8 @@ func checkAudioDuration(file multipart.File, kind string) error {
 	}()
 
 	duration := time.Duration(float64(streamer.Len())/float64(format.SampleRate)) * time.Second
-	if duration > MaxAudioDuration {
-		return fmt.Errorf("audio too long: %v (max %v)", duration, MaxAudioDuration)
+	if duration > policy.MaxAudioDuration() {
+		return fmt.Errorf("audio too long: %v (max %v)", duration, policy.MaxAudioDuration())
 	}
 
 	return nil
@@ -141,7 +161,7 @@ func checkAudioDuration(file multipart.File, kind string) error {
 
 // GenerateInternalFilename creates a unique internal filename based on the provided ID and MIME type.
 func GenerateInternalFilename(id, mimeType string) string {
-	return fmt.Sprintf("%s%s", id, AllowedTypes[mimeType])
+	return fmt.Sprintf("%s%s", id, SupportedTypes[mimeType])
 }
 
 // BuildBulkUploadResponse creates a structured response for bulk upload operations.
@@ -175,3 +195,85 @@ func BuildBulkUploadResponse(totalFiles int, successfulFiles []*models.UploadRes
 		Message:         message,
 	}
 }
+
+// BuildBatchResponse creates a structured response for batch operations on sounds.
+func BuildBatchResponse(successfulItems, failedItems []*models.BatchItemResult) models.BatchSoundsResponse {
+	successCount := len(successfulItems)
+	failureCount := len(failedItems)
+	totalItems := successCount + failureCount
+
+	var status string
+
+	var message string
+
+	switch {
+	case successCount == totalItems:
+		status = "success"
+		message = fmt.Sprintf("All %d operations completed successfully!", totalItems)
+	case successCount > 0:
+		status = "partial"
+		message = fmt.Sprintf("%d of %d operations completed successfully", successCount, totalItems)
+	default:
+		status = "failure"
+		message = "No operations were completed successfully"
+	}
+
+	return models.BatchSoundsResponse{
+		Status:          status,
+		TotalItems:      totalItems,
+		SuccessCount:    successCount,
+		FailureCount:    failureCount,
+		SuccessfulItems: successfulItems,
+		FailedItems:     failedItems,
+		Message:         message,
+	}
+}
+
+// UniqueFilename returns name, or name with a numeric suffix before its extension if it is already taken, and marks
+// the result as taken.
+func UniqueFilename(name string, taken map[string]bool) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+
+	taken[unique] = true
+
+	return unique
+}
+
+// ChecksumReader computes the checksum of a sound file as it is read through it.
+type ChecksumReader struct {
+	r    io.Reader
+	hash hash.Hash
+}
+
+// NewChecksumReader returns a ChecksumReader reading from r.
+func NewChecksumReader(r io.Reader) *ChecksumReader {
+	return &ChecksumReader{r: r, hash: sha256.New()}
+}
+
+func (c *ChecksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+
+	return n, err
+}
+
+// Checksum returns the checksum of what has been read so far, as a hex-encoded SHA-256 digest.
+func (c *ChecksumReader) Checksum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// Checksum reads r to the end and returns its checksum, as a hex-encoded SHA-256 digest.
+func Checksum(r io.Reader) (string, error) {
+	reader := NewChecksumReader(r)
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return "", err
+	}
+
+	return reader.Checksum(), nil
+}
@@ -0,0 +1,22 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestUniqueFilename(t *testing.T) {
+	t.Parallel()
+
+	taken := make(map[string]bool)
+
+	assert.Equal(t, "airhorn.mp3", utils.UniqueFilename("airhorn.mp3", taken))
+	assert.Equal(t, "airhorn (2).mp3", utils.UniqueFilename("airhorn.mp3", taken))
+	assert.Equal(t, "airhorn (3).mp3", utils.UniqueFilename("airhorn.mp3", taken))
+	assert.Equal(t, "global/airhorn.mp3", utils.UniqueFilename("global/airhorn.mp3", taken))
+	assert.Equal(t, "README", utils.UniqueFilename("README", taken))
+	assert.Equal(t, "README (2)", utils.UniqueFilename("README", taken))
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// DefaultPolicy returns the global upload policy, used where a guild has no overrides.
+func DefaultPolicy() *models.Policy {
+	return &models.Policy{
+		MaxAudioDurationMs: DefaultMaxAudioDuration.Milliseconds(),
+		MaxFilesPerUser:    DefaultMaxFilesPerUser,
+		MaxUploadSize:      DefaultMaxUploadSize,
+		MaxUserStorage:     DefaultMaxUserStorage,
+		MaxGuildStorage:    DefaultMaxGuildStorage,
+		AllowedTypes:       slices.Sorted(maps.Keys(SupportedTypes)),
+	}
+}
+
+// ResolvePolicy applies the overrides in the given guild settings on top of the global policy.
+func ResolvePolicy(setting *models.GuildSetting) *models.Policy {
+	policy := DefaultPolicy()
+	policy.RequireApproval = setting.RequireApproval
+
+	if setting.MaxAudioDurationMs != nil {
+		policy.MaxAudioDurationMs = *setting.MaxAudioDurationMs
+	}
+
+	if setting.MaxFilesPerUser != nil {
+		policy.MaxFilesPerUser = *setting.MaxFilesPerUser
+	}
+
+	if setting.MaxUploadSize != nil {
+		policy.MaxUploadSize = *setting.MaxUploadSize
+	}
+
+	if setting.MaxUserStorage != nil {
+		policy.MaxUserStorage = *setting.MaxUserStorage
+	}
+
+	if setting.MaxGuildStorage != nil {
+		policy.MaxGuildStorage = *setting.MaxGuildStorage
+	}
+
+	if len(setting.AllowedTypes) > 0 {
+		policy.AllowedTypes = setting.AllowedTypes
+	}
+
+	return policy
+}
+
+// ValidatePolicyOverrides checks that the policy overrides in the request are within the permitted bounds, and that
+// those being reset are not also being set. Storage quotas can also be set to NoStorageLimit.
+func ValidatePolicyOverrides(req *models.UpdateGuildSettingsRequest) error {
+	if req.MaxAudioDurationMs != nil &&
+		(*req.MaxAudioDurationMs < 0 || *req.MaxAudioDurationMs > MaxAudioDurationLimit.Milliseconds()) {
+		return fmt.Errorf("max_audio_duration_ms must be between 0 and %d", MaxAudioDurationLimit.Milliseconds())
+	}
+
+	if req.MaxFilesPerUser != nil && (*req.MaxFilesPerUser < 0 || *req.MaxFilesPerUser > MaxFilesPerUserLimit) {
+		return fmt.Errorf("max_files_per_user must be between 0 and %d", MaxFilesPerUserLimit)
+	}
+
+	if req.MaxUploadSize != nil && (*req.MaxUploadSize < 0 || *req.MaxUploadSize > MaxPayloadSize) {
+		return fmt.Errorf("max_upload_size must be between 0 and %d", MaxPayloadSize)
+	}
+
+	if req.MaxUserStorage != nil && !validStorageLimit(*req.MaxUserStorage, MaxUserStorageLimit) {
+		return fmt.Errorf("max_user_storage must be between 0 and %d, or %d for no limit", MaxUserStorageLimit, NoStorageLimit)
+	}
+
+	if req.MaxGuildStorage != nil && !validStorageLimit(*req.MaxGuildStorage, MaxGuildStorageLimit) {
+		return fmt.Errorf("max_guild_storage must be between 0 and %d, or %d for no limit", MaxGuildStorageLimit, NoStorageLimit)
+	}
+
+	if req.AllowedTypes != nil {
+		if len(*req.AllowedTypes) == 0 {
+			return errors.New("allowed_types must contain at least one type, use reset_overrides to allow every type")
+		}
+
+		for _, mimeType := range *req.AllowedTypes {
+			if _, ok := SupportedTypes[mimeType]; !ok {
+				return fmt.Errorf("allowed_types can only contain: %s", strings.Join(slices.Sorted(maps.Keys(SupportedTypes)), ", "))
+			}
+		}
+	}
+
+	set := map[string]bool{
+		"max_audio_duration_ms": req.MaxAudioDurationMs != nil,
+		"max_files_per_user":    req.MaxFilesPerUser != nil,
+		"max_upload_size":       req.MaxUploadSize != nil,
+		"max_user_storage":      req.MaxUserStorage != nil,
+		"max_guild_storage":     req.MaxGuildStorage != nil,
+		"allowed_types":         req.AllowedTypes != nil,
+	}
+
+	for _, name := range req.ResetOverrides {
+		if !slices.Contains(PolicyOverrides, name) {
+			return fmt.Errorf("reset_overrides can only contain: %s", strings.Join(PolicyOverrides, ", "))
+		}
+
+		if set[name] {
+			return fmt.Errorf("%s cannot be both set and reset", name)
+		}
+	}
+
+	return nil
+}
+
+// validStorageLimit checks that a storage quota is within the given limit, or disabled.
+func validStorageLimit(value, limit int64) bool {
+	return value == NoStorageLimit || (value >= 0 && value <= limit)
+}
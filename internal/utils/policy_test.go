@@ -0,0 +1,142 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestResolvePolicy(t *testing.T) {
+	t.Parallel()
+
+	maxDuration := int64(10_000)
+	maxFiles := 2
+
+	tests := []struct {
+		name    string
+		setting *models.GuildSetting
+		want    *models.Policy
+	}{
+		{
+			name:    "No overrides",
+			setting: &models.GuildSetting{},
+			want:    utils.DefaultPolicy(),
+		},
+		{
+			name: "Partial overrides",
+			setting: &models.GuildSetting{
+				MaxAudioDurationMs: &maxDuration,
+				MaxFilesPerUser:    &maxFiles,
+				AllowedTypes:       []string{"audio/mpeg"},
+			},
+			want: &models.Policy{
+				MaxAudioDurationMs: maxDuration,
+				MaxFilesPerUser:    maxFiles,
+				MaxUploadSize:      utils.DefaultMaxUploadSize,
+				MaxUserStorage:     utils.DefaultMaxUserStorage,
+				MaxGuildStorage:    utils.DefaultMaxGuildStorage,
+				AllowedTypes:       []string{"audio/mpeg"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, utils.ResolvePolicy(tt.setting))
+		})
+	}
+}
+
+func TestValidatePolicyOverrides(t *testing.T) {
+	t.Parallel()
+
+	tooLong := int64(60_000)
+	noFiles := 0
+	tooManyFiles := utils.MaxFilesPerUserLimit + 1
+	tooMuchStorage := int64(utils.MaxGuildStorageLimit + 1)
+	noStorageLimit := int64(utils.NoStorageLimit)
+	negativeStorage := int64(-2)
+
+	tests := []struct {
+		name    string
+		req     *models.UpdateGuildSettingsRequest
+		wantErr bool
+	}{
+		{
+			name:    "Zero is a limit",
+			req:     &models.UpdateGuildSettingsRequest{MaxFilesPerUser: &noFiles},
+			wantErr: false,
+		},
+		{
+			name:    "Reset to default",
+			req:     &models.UpdateGuildSettingsRequest{ResetOverrides: []string{"max_files_per_user", "allowed_types"}},
+			wantErr: false,
+		},
+		{
+			name:    "Reset of an unknown override",
+			req:     &models.UpdateGuildSettingsRequest{ResetOverrides: []string{"report_threshold"}},
+			wantErr: true,
+		},
+		{
+			name: "Set and reset together",
+			req: &models.UpdateGuildSettingsRequest{
+				MaxFilesPerUser: &noFiles,
+				ResetOverrides:  []string{"max_files_per_user"},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "No storage limit",
+			req:     &models.UpdateGuildSettingsRequest{MaxUserStorage: &noStorageLimit, MaxGuildStorage: &noStorageLimit},
+			wantErr: false,
+		},
+		{
+			name:    "Negative storage",
+			req:     &models.UpdateGuildSettingsRequest{MaxUserStorage: &negativeStorage},
+			wantErr: true,
+		},
+		{
+			name:    "Duration above limit",
+			req:     &models.UpdateGuildSettingsRequest{MaxAudioDurationMs: &tooLong},
+			wantErr: true,
+		},
+		{
+			name:    "Too many files",
+			req:     &models.UpdateGuildSettingsRequest{MaxFilesPerUser: &tooManyFiles},
+			wantErr: true,
+		},
+		{
+			name:    "Guild storage above limit",
+			req:     &models.UpdateGuildSettingsRequest{MaxGuildStorage: &tooMuchStorage},
+			wantErr: true,
+		},
+		{
+			name:    "No allowed types",
+			req:     &models.UpdateGuildSettingsRequest{AllowedTypes: &[]string{}},
+			wantErr: true,
+		},
+		{
+			name:    "Unsupported type",
+			req:     &models.UpdateGuildSettingsRequest{AllowedTypes: &[]string{"audio/ogg"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := utils.ValidatePolicyOverrides(tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
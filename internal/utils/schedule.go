@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// InQuietHours checks whether the given time falls within the guild's quiet hours. Quiet hours may wrap
+// around midnight, e.g. 22:00 to 07:00.
+func InQuietHours(setting *models.GuildSetting, now time.Time) bool {
+	if setting.QuietHoursStart == nil || setting.QuietHoursEnd == nil {
+		return false
+	}
+
+	location, err := time.LoadLocation(setting.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	start, errStart := time.Parse(QuietHoursLayout, *setting.QuietHoursStart)
+	end, errEnd := time.Parse(QuietHoursLayout, *setting.QuietHoursEnd)
+
+	if errStart != nil || errEnd != nil {
+		return false
+	}
+
+	now = now.In(location)
+	current := now.Hour()*60 + now.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute <= endMinute {
+		return current >= startMinute && current < endMinute
+	}
+
+	return current >= startMinute || current < endMinute
+}
+
+// ValidateGuildSchedule checks that the quiet hours and timezone in the request are valid. Quiet hours must be set or
+// cleared as a whole, as a single bound never silences anything.
+func ValidateGuildSchedule(req *models.UpdateGuildSettingsRequest) error {
+	start, end := req.QuietHoursStart, req.QuietHoursEnd
+	if (start == nil) != (end == nil) || (start != nil && (*start == "") != (*end == "")) {
+		return errors.New("quiet_hours_start and quiet_hours_end must be set or cleared together")
+	}
+
+	for name, value := range map[string]*string{
+		"quiet_hours_start": req.QuietHoursStart,
+		"quiet_hours_end":   req.QuietHoursEnd,
+	} {
+		if value == nil || *value == "" {
+			continue
+		}
+
+		if _, err := time.Parse(QuietHoursLayout, *value); err != nil {
+			return fmt.Errorf("%s must be in HH:MM format", name)
+		}
+	}
+
+	if req.Timezone != nil {
+		if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+			return fmt.Errorf("unknown timezone: %s", *req.Timezone)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestInQuietHours(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		start    string
+		end      string
+		timezone string
+		now      time.Time
+		want     bool
+	}{
+		{
+			name:     "Within same-day window",
+			start:    "09:00",
+			end:      "17:00",
+			timezone: "UTC",
+			now:      time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "End of window is exclusive",
+			start:    "09:00",
+			end:      "17:00",
+			timezone: "UTC",
+			now:      time.Date(2025, time.January, 1, 17, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "After midnight in wrapping window",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "UTC",
+			now:      time.Date(2025, time.January, 1, 3, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+		{
+			name:     "Outside wrapping window",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "UTC",
+			now:      time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC),
+			want:     false,
+		},
+		{
+			name:     "Guild timezone is respected",
+			start:    "22:00",
+			end:      "07:00",
+			timezone: "Australia/Sydney",
+			now:      time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC), // 23:00 in Sydney
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			setting := &models.GuildSetting{
+				QuietHoursStart: &tt.start,
+				QuietHoursEnd:   &tt.end,
+				Timezone:        tt.timezone,
+			}
+
+			assert.Equal(t, tt.want, utils.InQuietHours(setting, tt.now))
+		})
+	}
+}
+
+func TestValidateGuildSchedule(t *testing.T) {
+	t.Parallel()
+
+	start, end, cleared, invalid := "22:00", "07:00", "", "25:00"
+
+	tests := []struct {
+		name    string
+		start   *string
+		end     *string
+		wantErr bool
+	}{
+		{
+			name: "Quiet hours left alone",
+		},
+		{
+			name:  "Both bounds set",
+			start: &start,
+			end:   &end,
+		},
+		{
+			name:  "Both bounds cleared",
+			start: &cleared,
+			end:   &cleared,
+		},
+		{
+			name:    "Only start set",
+			start:   &start,
+			wantErr: true,
+		},
+		{
+			name:    "Only end set",
+			end:     &end,
+			wantErr: true,
+		},
+		{
+			name:    "One bound cleared",
+			start:   &start,
+			end:     &cleared,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid bound",
+			start:   &invalid,
+			end:     &end,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := utils.ValidateGuildSchedule(&models.UpdateGuildSettingsRequest{
+				QuietHoursStart: tt.start,
+				QuietHoursEnd:   tt.end,
+			})
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+)
+
+// customEmojiPattern matches Discord custom emoji, e.g. <:name:123456789012345678> or <a:name:123456789012345678>.
+var customEmojiPattern = regexp.MustCompile(`^<a?:\w{2,32}:\d{17,20}>$`)
+
+// NormalizeSoundMetadata trims the metadata in the request, lowercases and de-duplicates tags, and checks that the
+// result is within the permitted limits.
+func NormalizeSoundMetadata(req *models.UpdateSoundRequest) error {
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if utf8.RuneCountInString(displayName) > MaxDisplayNameLen {
+			return fmt.Errorf("display_name can be at most %d characters", MaxDisplayNameLen)
+		}
+
+		req.DisplayName = &displayName
+	}
+
+	if req.Emoji != nil {
+		emoji := strings.TrimSpace(*req.Emoji)
+		if emoji != "" && !isEmoji(emoji) {
+			return fmt.Errorf("emoji must be a single emoji or a Discord custom emoji")
+		}
+
+		req.Emoji = &emoji
+	}
+
+	if req.Tags != nil {
+		tags := make([]string, 0, len(*req.Tags))
+
+		for _, tag := range *req.Tags {
+			tag = strings.ToLower(strings.TrimSpace(tag))
+			if tag == "" || slices.Contains(tags, tag) {
+				continue
+			}
+
+			if utf8.RuneCountInString(tag) > MaxTagLen {
+				return fmt.Errorf("tags can be at most %d characters each", MaxTagLen)
+			}
+
+			tags = append(tags, tag)
+		}
+
+		if len(tags) > MaxTags {
+			return fmt.Errorf("a sound can have at most %d tags", MaxTags)
+		}
+
+		req.Tags = &tags
+	}
+
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > MaxDescriptionLen {
+			return fmt.Errorf("description can be at most %d characters", MaxDescriptionLen)
+		}
+
+		req.Description = &description
+	}
+
+	return nil
+}
+
+// isEmoji loosely checks that the value is a Discord custom emoji, or a unicode emoji sequence: at least one symbol,
+// and no letters, digits, spaces or control characters.
+func isEmoji(value string) bool {
+	if len(value) > MaxEmojiLen {
+		return false
+	}
+
+	if customEmojiPattern.MatchString(value) {
+		return true
+	}
+
+	hasSymbol := false
+
+	for _, r := range value {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.IsSpace(r), unicode.IsControl(r):
+			return false
+		case unicode.Is(unicode.So, r):
+			hasSymbol = true
+		}
+	}
+
+	return hasSymbol
+}
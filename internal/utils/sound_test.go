@@ -0,0 +1,80 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
+	"github.com/mocbotau/api-join-sound/internal/utils"
+)
+
+func TestNormalizeSoundMetadata(t *testing.T) {
+	t.Parallel()
+
+	strPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		req      models.UpdateSoundRequest
+		wantErr  bool
+		wantTags []string
+	}{
+		{
+			name: "Unicode emoji",
+			req:  models.UpdateSoundRequest{Emoji: strPtr("🎺")},
+		},
+		{
+			name: "Emoji sequence",
+			req:  models.UpdateSoundRequest{Emoji: strPtr("👩‍🚀")},
+		},
+		{
+			name: "Custom emoji",
+			req:  models.UpdateSoundRequest{Emoji: strPtr("<:trumpet:123456789012345678>")},
+		},
+		{
+			name: "Clearing the emoji",
+			req:  models.UpdateSoundRequest{Emoji: strPtr("")},
+		},
+		{
+			name:    "Text is not an emoji",
+			req:     models.UpdateSoundRequest{Emoji: strPtr("trumpet")},
+			wantErr: true,
+		},
+		{
+			name:    "Long display name",
+			req:     models.UpdateSoundRequest{DisplayName: strPtr(strings.Repeat("a", 51))},
+			wantErr: true,
+		},
+		{
+			name:     "Tags are normalized",
+			req:      models.UpdateSoundRequest{Tags: &[]string{" Meme ", "meme", "", "loud"}},
+			wantTags: []string{"meme", "loud"},
+		},
+		{
+			name:    "Too many tags",
+			req:     models.UpdateSoundRequest{Tags: &[]string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := utils.NormalizeSoundMetadata(&tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.wantTags != nil {
+				assert.Equal(t, tt.wantTags, *tt.req.Tags)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package utils
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/mocbotau/api-join-sound/internal/models"
 )
 
 // GetUserGuildID extracts the guild ID and user ID from the request context.
@@ -21,3 +24,84 @@ func GetUserGuildID(c *gin.Context) (guildID, userID int64, err error) {
 
 	return guildID, userID, nil
 }
+
+// GetGuildID extracts the guild ID from the request context.
+func GetGuildID(c *gin.Context) (int64, error) {
+	guildID, err := strconv.ParseInt(c.Param("guildId"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid guild ID: %w", err)
+	}
+
+	return guildID, nil
+}
+
+// GetUserID extracts the user ID from the request context.
+func GetUserID(c *gin.Context) (int64, error) {
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GetPlayFilter extracts the time range and pagination parameters from the request query.
+func GetPlayFilter(c *gin.Context) (*models.PlayFilter, error) {
+	filter := &models.PlayFilter{}
+
+	var err error
+
+	if filter.Limit, filter.Offset, err = GetPagination(c); err != nil {
+		return nil, err
+	}
+
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return nil, err
+	}
+
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return nil, err
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+
+	return filter, nil
+}
+
+// GetPagination extracts the limit and offset parameters from the request query.
+func GetPagination(c *gin.Context) (limit, offset int, err error) {
+	limit = DefaultPageSize
+
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", MaxPageSize)
+		}
+	}
+
+	if value := c.Query("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// parseTimeQuery parses an optional RFC 3339 timestamp from the request query.
+func parseTimeQuery(c *gin.Context, param string) (*time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, nil //nolint:nilnil // an absent bound is not an error
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", param)
+	}
+
+	return &parsed, nil
+}